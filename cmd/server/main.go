@@ -1,8 +1,34 @@
 package main
 
-import "webServerEx/internal/pkg/app"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	_ "time/tzdata"
+	"webServerEx/internal/config"
+	"webServerEx/internal/pkg/app"
+)
 
 func main() {
-	application := app.NewApp()
-	application.Start()
+	cfg, opts, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if opts.PrintConfig {
+		fmt.Println(cfg)
+		return
+	}
+
+	application, err := app.NewApp(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize: %v", err)
+	}
+	if err := application.Start(); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
 }
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const KindJWT = "jwt"
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrMissingSubject   = errors.New("token has no subject")
+)
+
+type JWTOptions struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+	Now      func() time.Time
+}
+
+type Claims map[string]any
+
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	v, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
+
+type JWTVerifier struct {
+	keys KeyProvider
+	opts JWTOptions
+}
+
+func NewJWTVerifier(keys KeyProvider, opts JWTOptions) *JWTVerifier {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &JWTVerifier{keys: keys, opts: opts}
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformedToken
+	}
+	return nil
+}
+
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	key, err := v.keys.Key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func verifySignature(alg string, key any, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: %s with non-HMAC key", ErrUnsupportedAlg, alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s with non-RSA key", ErrUnsupportedAlg, alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s with non-ECDSA key", ErrUnsupportedAlg, alg)
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+}
+
+func (v *JWTVerifier) validateClaims(claims Claims) error {
+	now := v.opts.Now()
+	exp, ok := claims.time("exp")
+	if !ok || now.After(exp.Add(v.opts.Leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(v.opts.Leeway).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+	if v.opts.Issuer != "" && claims.String("iss") != v.opts.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.opts.Audience != "" {
+		found := false
+		for _, aud := range claims.Strings("aud") {
+			if aud == v.opts.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrInvalidAudience
+		}
+	}
+	if claims.String("sub") == "" {
+		return ErrMissingSubject
+	}
+	return nil
+}
+
+func ClaimsPrincipal(claims Claims) *Principal {
+	name := claims.String("name")
+	if name == "" {
+		name = claims.String("preferred_username")
+	}
+	if name == "" {
+		name = claims.String("sub")
+	}
+	scopes := claims.Strings("scope")
+	if len(scopes) == 0 {
+		scopes = claims.Strings("scp")
+	}
+	principal := &Principal{
+		ID:     claims.String("sub"),
+		Kind:   KindJWT,
+		Name:   name,
+		Scopes: scopes,
+		Roles:  claims.Strings("roles"),
+	}
+	principal.Timezone = claims.String("zoneinfo")
+	principal.TenantID = claims.String("tenant")
+	if principal.TenantID == "" {
+		principal.TenantID = claims.String("tid")
+	}
+	return principal
+}
+
+type jwtAuthenticator struct {
+	verifier *JWTVerifier
+}
+
+func NewJWTAuthenticator(verifier *JWTVerifier) Authenticator {
+	return &jwtAuthenticator{verifier: verifier}
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return ClaimsPrincipal(claims), nil
+}
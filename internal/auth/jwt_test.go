@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signToken(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT", "kid": kid}
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "user-1",
+		"name":  "Alice",
+		"iss":   "https://sso.example.com",
+		"aud":   []string{"todos", "other"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"nbf":   testNow.Add(-time.Minute).Unix(),
+		"scope": "tasks:read tasks:write",
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestJWTVerify(t *testing.T) {
+	secret := []byte("secret")
+	opts := JWTOptions{
+		Issuer:   "https://sso.example.com",
+		Audience: "todos",
+		Now:      func() time.Time { return testNow },
+	}
+	verifier := NewJWTVerifier(NewStaticSecret(string(secret)), opts)
+
+	t.Run("verify HS256 token", func(t *testing.T) {
+		token := signToken(t, "HS256", "", secret, validClaims())
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		principal := ClaimsPrincipal(claims)
+		if principal.ID != "user-1" || principal.Name != "Alice" || principal.Kind != KindJWT {
+			t.Errorf("uncorrect principal: %v", principal)
+		}
+		if !principal.HasScope(ScopeTasksWrite) {
+			t.Errorf("uncorrect scopes: %v", principal.Scopes)
+		}
+	})
+
+	t.Run("verify wrong claims", func(t *testing.T) {
+		tableTests := []struct {
+			name  string
+			claim string
+			value any
+			err   error
+		}{
+			{name: "expired", claim: "exp", value: testNow.Add(-time.Hour).Unix(), err: ErrTokenExpired},
+			{name: "no exp", claim: "exp", value: nil, err: ErrTokenExpired},
+			{name: "not yet valid", claim: "nbf", value: testNow.Add(time.Hour).Unix(), err: ErrTokenNotYetValid},
+			{name: "wrong issuer", claim: "iss", value: "https://evil.example.com", err: ErrInvalidIssuer},
+			{name: "wrong audience", claim: "aud", value: "other", err: ErrInvalidAudience},
+			{name: "no subject", claim: "sub", value: nil, err: ErrMissingSubject},
+		}
+		for _, tt := range tableTests {
+			claims := validClaims()
+			if tt.value == nil {
+				delete(claims, tt.claim)
+			} else {
+				claims[tt.claim] = tt.value
+			}
+			token := signToken(t, "HS256", "", secret, claims)
+
+			_, err := verifier.Verify(token)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+			}
+		}
+	})
+
+	t.Run("verify tampered token", func(t *testing.T) {
+		token := signToken(t, "HS256", "", []byte("other secret"), validClaims())
+
+		_, err := verifier.Verify(token)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+		_, err = verifier.Verify("not.a.token")
+		if !errors.Is(err, ErrMalformedToken) {
+			t.Errorf("expected ErrMalformedToken, got %v", err)
+		}
+		_, err = verifier.Verify(signToken(t, "none", "", secret, validClaims()))
+		if !errors.Is(err, ErrUnsupportedAlg) {
+			t.Errorf("expected ErrUnsupportedAlg, got %v", err)
+		}
+	})
+}
+
+func TestJWKSFile(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaJWK := map[string]string{
+		"kid": "rsa-1", "kty": "RSA", "use": "sig",
+		"n": b64(rsaKey.N.Bytes()), "e": b64([]byte{1, 0, 1}),
+	}
+	ecJWK := map[string]string{
+		"kid": "ec-1", "kty": "EC", "crv": "P-256",
+		"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+	}
+	opts := JWTOptions{Now: func() time.Time { return testNow }}
+
+	t.Run("verify RS256 and ES256 tokens", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		writeJWKS(t, path, rsaJWK, ecJWK)
+		jwks, err := NewJWKSFile(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		verifier := NewJWTVerifier(jwks, opts)
+
+		if _, err := verifier.Verify(signToken(t, "RS256", "rsa-1", rsaKey, validClaims())); err != nil {
+			t.Errorf("RS256: %v", err)
+		}
+		if _, err := verifier.Verify(signToken(t, "ES256", "ec-1", ecKey, validClaims())); err != nil {
+			t.Errorf("ES256: %v", err)
+		}
+		_, err = verifier.Verify(signToken(t, "HS256", "rsa-1", []byte("guess"), validClaims()))
+		if !errors.Is(err, ErrUnsupportedAlg) {
+			t.Errorf("expected ErrUnsupportedAlg, got %v", err)
+		}
+		_, err = verifier.Verify(signToken(t, "RS256", "rsa-2", rsaKey, validClaims()))
+		if !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected ErrUnknownKey, got %v", err)
+		}
+	})
+
+	t.Run("reload rotated keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		writeJWKS(t, path, rsaJWK)
+		jwks, err := NewJWKSFile(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		writeJWKS(t, path, ecJWK)
+		if err := jwks.Reload(); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := jwks.Key("ec-1"); err != nil {
+			t.Error(err.Error())
+		}
+		if _, err := jwks.Key("rsa-1"); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected ErrUnknownKey, got %v", err)
+		}
+	})
+
+	t.Run("parse invalid JWKS", func(t *testing.T) {
+		_, err := ParseJWKS([]byte(`{"keys":[{"kid":"x","kty":"EC","crv":"P-384"}]}`))
+		if !errors.Is(err, ErrUnsupportedKey) {
+			t.Errorf("expected ErrUnsupportedKey, got %v", err)
+		}
+	})
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("secret")
+	verifier := NewJWTVerifier(NewStaticSecret(string(secret)), JWTOptions{Now: func() time.Time { return testNow }})
+	store := NewKeyStore()
+	apiKey, _, _ := store.Mint("ci", "", []string{ScopeTasksRead})
+	var principal *Principal
+	handler := Middleware(NewAPIKeyAuthenticator(store), NewJWTAuthenticator(verifier))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = PrincipalFromContext(r.Context())
+		}))
+
+	for _, tt := range []struct {
+		token string
+		kind  string
+	}{
+		{token: signToken(t, "HS256", "", secret, validClaims()), kind: KindJWT},
+		{token: apiKey, kind: KindAPIKey},
+	} {
+		principal = nil
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.token))
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if principal == nil || principal.Kind != tt.kind {
+			t.Errorf("expected %s principal, got %v", tt.kind, principal)
+		}
+	}
+}
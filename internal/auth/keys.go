@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyPrefix = "tsk_"
+
+var (
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrInvalidName  = errors.New("invalid api key name")
+	ErrInvalidHash  = errors.New("invalid api key hash")
+)
+
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	TenantID  string     `json:"tenant_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	hash      string
+}
+
+func (k *APIKey) Principal() *Principal {
+	return &Principal{
+		ID:       k.ID,
+		Kind:     KindAPIKey,
+		Name:     k.Name,
+		Scopes:   k.Scopes,
+		TenantID: k.TenantID,
+	}
+}
+
+type KeyStore interface {
+	Mint(name, tenantID string, scopes []string) (string, *APIKey, error)
+	Import(name, tenantID, hash string, scopes []string) (*APIKey, error)
+	List() []*APIKey
+	Revoke(id string) error
+	Lookup(key string) (*APIKey, error)
+}
+
+type keyStore struct {
+	mu     sync.RWMutex
+	byID   map[string]*APIKey
+	byHash map[string]*APIKey
+}
+
+func NewKeyStore() KeyStore {
+	return &keyStore{
+		byID:   make(map[string]*APIKey),
+		byHash: make(map[string]*APIKey),
+	}
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return errors.Join(ErrInvalidScope, errors.New(scope))
+		}
+	}
+	return nil
+}
+
+func (s *keyStore) Mint(name, tenantID string, scopes []string) (string, *APIKey, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	key := keyPrefix + secret
+	apiKey, err := s.add(name, tenantID, key[:len(keyPrefix)+6], HashKey(key), scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+func (s *keyStore) Import(name, tenantID, hash string, scopes []string) (*APIKey, error) {
+	return s.add(name, tenantID, "", hash, scopes)
+}
+
+func (s *keyStore) add(name, tenantID, prefix, hash string, scopes []string) (*APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrInvalidName
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, err
+	}
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha256.Size*2 {
+		return nil, ErrInvalidHash
+	}
+	id, err := randomToken(9)
+	if err != nil {
+		return nil, err
+	}
+	apiKey := &APIKey{
+		ID:        id,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		hash:      strings.ToLower(hash),
+	}
+	s.mu.Lock()
+	s.byID[apiKey.ID] = apiKey
+	s.byHash[apiKey.hash] = apiKey
+	copied := *apiKey
+	s.mu.Unlock()
+	return &copied, nil
+}
+
+func (s *keyStore) List() []*APIKey {
+	s.mu.RLock()
+	keys := make([]*APIKey, 0, len(s.byID))
+	for _, key := range s.byID {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	s.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+func (s *keyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	apiKey, ok := s.byID[id]
+	if !ok || apiKey.RevokedAt != nil {
+		return ErrKeyNotFound
+	}
+	now := time.Now().UTC()
+	apiKey.RevokedAt = &now
+	delete(s.byHash, apiKey.hash)
+	return nil
+}
+
+func (s *keyStore) Lookup(key string) (*APIKey, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	apiKey, ok := s.byHash[HashKey(key)]
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	copied := *apiKey
+	return &copied, nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeyStoreMint(t *testing.T) {
+	t.Run("mint and lookup key", func(t *testing.T) {
+		store := NewKeyStore()
+
+		key, apiKey, err := store.Mint("ci", "", []string{ScopeTasksRead})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		found, err := store.Lookup(key)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if found.ID != apiKey.ID {
+			t.Errorf("uncorrect key id: %s", found.ID)
+		}
+		if apiKey.hash == key {
+			t.Error("key must be stored hashed")
+		}
+	})
+
+	t.Run("mint invalid key", func(t *testing.T) {
+		store := NewKeyStore()
+
+		_, _, err := store.Mint("", "", []string{ScopeTasksRead})
+		if !errors.Is(err, ErrInvalidName) {
+			t.Errorf("expected ErrInvalidName, got %v", err)
+		}
+		_, _, err = store.Mint("ci", "", []string{"tasks:everything"})
+		if !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("expected ErrInvalidScope, got %v", err)
+		}
+		_, _, err = store.Mint("ci", "", nil)
+		if !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("expected ErrInvalidScope, got %v", err)
+		}
+	})
+
+	t.Run("mint concurrently with list", func(t *testing.T) {
+		store := NewKeyStore()
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				store.Mint("ci", "", []string{ScopeTasksRead})
+			}()
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					store.List()
+				}
+			}()
+		}
+		wg.Wait()
+		for _, apiKey := range store.List() {
+			if !strings.HasPrefix(apiKey.Prefix, keyPrefix) {
+				t.Errorf("uncorrect key prefix: %q", apiKey.Prefix)
+			}
+		}
+	})
+
+	t.Run("import hashed key", func(t *testing.T) {
+		store := NewKeyStore()
+
+		_, err := store.Import("admin", "", HashKey("tsk_secret"), []string{ScopeTasksAdmin})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := store.Lookup("tsk_secret"); err != nil {
+			t.Error(err.Error())
+		}
+		_, err = store.Import("admin", "", "not-a-hash", []string{ScopeTasksAdmin})
+		if !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("expected ErrInvalidHash, got %v", err)
+		}
+	})
+}
+
+func TestKeyStoreRevoke(t *testing.T) {
+	t.Run("revoke key", func(t *testing.T) {
+		store := NewKeyStore()
+		key, apiKey, _ := store.Mint("ci", "", []string{ScopeTasksRead})
+
+		if err := store.Revoke(apiKey.ID); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := store.Lookup(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("expected ErrInvalidKey, got %v", err)
+		}
+		keys := store.List()
+		if len(keys) != 1 || keys[0].RevokedAt == nil {
+			t.Errorf("revoked key must stay listed: %v", keys)
+		}
+	})
+
+	t.Run("revoke wrong id", func(t *testing.T) {
+		store := NewKeyStore()
+
+		err := store.Revoke("missing")
+		if !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("expected ErrKeyNotFound, got %v", err)
+		}
+	})
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	admin := &Principal{Scopes: []string{ScopeTasksAdmin}}
+	reader := &Principal{Scopes: []string{ScopeTasksRead}}
+
+	if !admin.HasScope(ScopeTasksRead) || !admin.HasScope(ScopeTasksWrite) {
+		t.Error("admin scope must imply read and write")
+	}
+	if reader.HasScope(ScopeTasksWrite) {
+		t.Error("read scope must not imply write")
+	}
+	var nobody *Principal
+	if nobody.HasScope(ScopeTasksRead) {
+		t.Error("nil principal must not have scopes")
+	}
+}
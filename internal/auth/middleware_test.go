@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	store := NewKeyStore()
+	readKey, _, _ := store.Mint("reader", "", []string{ScopeTasksRead})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Middleware(NewAPIKeyAuthenticator(store))(RequireScope(ScopeTasksRead, ok))
+	writeHandler := Middleware(NewAPIKeyAuthenticator(store))(RequireScope(ScopeTasksWrite, ok))
+
+	tableTests := []struct {
+		name    string
+		handler http.Handler
+		header  string
+		code    int
+	}{
+		{name: "valid key", handler: handler, header: "Bearer " + readKey, code: http.StatusOK},
+		{name: "no header", handler: handler, header: "", code: http.StatusUnauthorized},
+		{name: "unknown key", handler: handler, header: "Bearer tsk_unknown", code: http.StatusUnauthorized},
+		{name: "wrong scheme", handler: handler, header: "Basic " + readKey, code: http.StatusUnauthorized},
+		{name: "missing scope", handler: writeHandler, header: "Bearer " + readKey, code: http.StatusForbidden},
+	}
+
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeTasksAdmin = "tasks:admin"
+)
+
+var scopeImplies = map[string][]string{
+	ScopeTasksAdmin: {ScopeTasksWrite, ScopeTasksRead},
+	ScopeTasksWrite: {ScopeTasksRead},
+}
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var scopeRoles = map[string]string{
+	ScopeTasksRead:  RoleViewer,
+	ScopeTasksWrite: RoleEditor,
+	ScopeTasksAdmin: RoleAdmin,
+}
+
+func ValidScope(scope string) bool {
+	switch scope {
+	case ScopeTasksRead, ScopeTasksWrite, ScopeTasksAdmin:
+		return true
+	}
+	return false
+}
+
+const (
+	KindAPIKey = "api_key"
+)
+
+type Principal struct {
+	ID       string   `json:"id"`
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	TenantID string   `json:"tenant_id,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope || slices.Contains(scopeImplies[granted], scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) EffectiveRoles() []string {
+	roles := slices.Clone(p.Roles)
+	for _, scope := range p.Scopes {
+		if role, ok := scopeRoles[scope]; ok && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func (p *Principal) Subject() string {
+	return p.Kind + ":" + p.ID
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	SessionCookieName = "session"
+	CSRFHeaderName    = "X-CSRF-Token"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found or expired")
+	ErrInvalidCSRF     = errors.New("missing or invalid CSRF token")
+)
+
+type Session struct {
+	UserID    string    `json:"user_id"`
+	CSRFToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SessionStore interface {
+	Create(userID string) (string, *Session, error)
+	Get(token string) (*Session, error)
+	Delete(token string)
+	DeleteExpired()
+}
+
+type sessionStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewSessionStore(ttl time.Duration) SessionStore {
+	return &sessionStore{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]*Session),
+	}
+}
+
+func (s *sessionStore) Create(userID string) (string, *Session, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	csrf, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	session := &Session{
+		UserID:    userID,
+		CSRFToken: csrf,
+		ExpiresAt: s.now().Add(s.ttl),
+	}
+	s.mu.Lock()
+	s.sessions[HashKey(token)] = session
+	s.mu.Unlock()
+	return token, session, nil
+}
+
+func (s *sessionStore) Get(token string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := HashKey(token)
+	session, ok := s.sessions[key]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		delete(s.sessions, key)
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *sessionStore) Delete(token string) {
+	s.mu.Lock()
+	delete(s.sessions, HashKey(token))
+	s.mu.Unlock()
+}
+
+func (s *sessionStore) DeleteExpired() {
+	now := s.now()
+	s.mu.Lock()
+	for key, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+func CleanupSessions(ctx context.Context, sessions SessionStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sessions.DeleteExpired()
+		}
+	}
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+type sessionAuthenticator struct {
+	sessions SessionStore
+	users    UserStore
+}
+
+func NewSessionAuthenticator(sessions SessionStore, users UserStore) Authenticator {
+	return &sessionAuthenticator{sessions: sessions, users: users}
+}
+
+func (a *sessionAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if _, ok := BearerToken(r); ok {
+		return nil, ErrNoCredentials
+	}
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoCredentials
+	}
+	session, err := a.sessions.Get(cookie.Value)
+	if err != nil {
+		return nil, ErrNoCredentials
+	}
+	if !safeMethod(r.Method) {
+		token := r.Header.Get(CSRFHeaderName)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) != 1 {
+			return nil, ErrInvalidCSRF
+		}
+	}
+	user, err := a.users.Get(session.UserID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return user.Principal(), nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	KindSession = "session"
+
+	minPasswordLength = 8
+	maxPasswordLength = 256
+)
+
+var (
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters")
+	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+)
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Roles        []string  `json:"roles"`
+	TenantID     string    `json:"tenant_id,omitempty"`
+	Timezone     string    `json:"timezone,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	passwordHash string
+}
+
+func (u *User) Principal() *Principal {
+	return &Principal{
+		ID:       u.ID,
+		Kind:     KindSession,
+		Name:     u.Username,
+		Roles:    u.Roles,
+		TenantID: u.TenantID,
+		Timezone: u.Timezone,
+	}
+}
+
+type UserStore interface {
+	Register(username, password, tenantID string) (*User, error)
+	Authenticate(username, password string) (*User, error)
+	Get(id string) (*User, error)
+	SetTimezone(id, timezone string) error
+}
+
+type userStore struct {
+	iterations int
+	dummyHash  string
+
+	mu         sync.RWMutex
+	byID       map[string]*User
+	byUsername map[string]*User
+}
+
+func NewUserStore(iterations int) (UserStore, error) {
+	dummyHash, err := HashPassword("dummy password", iterations)
+	if err != nil {
+		return nil, err
+	}
+	return &userStore{
+		iterations: iterations,
+		dummyHash:  dummyHash,
+		byID:       make(map[string]*User),
+		byUsername: make(map[string]*User),
+	}, nil
+}
+
+func normalizeUsername(username string) (string, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if len(username) < 3 || len(username) > 64 {
+		return "", ErrInvalidUsername
+	}
+	for _, ch := range username {
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '.' || ch == '_' || ch == '-') {
+			return "", ErrInvalidUsername
+		}
+	}
+	return username, nil
+}
+
+func (s *userStore) Register(username, password, tenantID string) (*User, error) {
+	username, err := normalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength || length > maxPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	hash, err := HashPassword(password, s.iterations)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomToken(9)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{
+		ID:           id,
+		Username:     username,
+		Roles:        []string{RoleEditor},
+		TenantID:     tenantID,
+		CreatedAt:    time.Now().UTC(),
+		passwordHash: hash,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byUsername[username]; ok {
+		return nil, ErrUserExists
+	}
+	s.byID[user.ID] = user
+	s.byUsername[username] = user
+	return user, nil
+}
+
+func (s *userStore) Authenticate(username, password string) (*User, error) {
+	username, _ = normalizeUsername(username)
+	s.mu.RLock()
+	user, ok := s.byUsername[username]
+	s.mu.RUnlock()
+	hash := s.dummyHash
+	if ok {
+		hash = user.passwordHash
+	}
+	match, err := CheckPassword(hash, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !match {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (s *userStore) Get(id string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.byID[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *userStore) SetTimezone(id, timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.byID[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	updated := *user
+	updated.Timezone = timezone
+	s.byID[id] = &updated
+	s.byUsername[updated.Username] = &updated
+	return nil
+}
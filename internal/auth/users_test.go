@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testIterations = 1000
+
+func newTestUserStore(t *testing.T) UserStore {
+	t.Helper()
+	users, err := NewUserStore(testIterations)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return users
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse", testIterations)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ok, _ := CheckPassword(hash, "correct horse"); !ok {
+		t.Error("expected password to match")
+	}
+	if ok, _ := CheckPassword(hash, "wrong horse"); ok {
+		t.Error("expected password not to match")
+	}
+	if _, err := CheckPassword("md5$abc", "x"); !errors.Is(err, ErrInvalidPasswordHash) {
+		t.Errorf("expected ErrInvalidPasswordHash, got %v", err)
+	}
+	other, _ := HashPassword("correct horse", testIterations)
+	if other == hash {
+		t.Error("hashes of the same password must use different salts")
+	}
+}
+
+func TestUserStore(t *testing.T) {
+	t.Run("register and authenticate", func(t *testing.T) {
+		users := newTestUserStore(t)
+
+		user, err := users.Register("Alice", "password123", "")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if user.Username != "alice" {
+			t.Errorf("uncorrect username: %s", user.Username)
+		}
+		found, err := users.Authenticate("alice", "password123")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if found.ID != user.ID {
+			t.Errorf("uncorrect user id: %s", found.ID)
+		}
+	})
+
+	t.Run("register wrong users", func(t *testing.T) {
+		users := newTestUserStore(t)
+		users.Register("alice", "password123", "")
+
+		tableTests := []struct {
+			username string
+			password string
+			err      error
+		}{
+			{username: "alice", password: "password123", err: ErrUserExists},
+			{username: "ALICE", password: "password123", err: ErrUserExists},
+			{username: "al", password: "password123", err: ErrInvalidUsername},
+			{username: "bob smith", password: "password123", err: ErrInvalidUsername},
+			{username: "bob", password: "short", err: ErrWeakPassword},
+		}
+		for _, tt := range tableTests {
+			_, err := users.Register(tt.username, tt.password, "")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected %v, got %v", tt.username, tt.err, err)
+			}
+		}
+	})
+
+	t.Run("authenticate wrong credentials", func(t *testing.T) {
+		users := newTestUserStore(t)
+		users.Register("alice", "password123", "")
+
+		if _, err := users.Authenticate("alice", "password124"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+		if _, err := users.Authenticate("nobody", "password123"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+}
+
+func TestSessionStore(t *testing.T) {
+	t.Run("session expiry", func(t *testing.T) {
+		sessions := NewSessionStore(time.Hour).(*sessionStore)
+		now := time.Now()
+		sessions.now = func() time.Time { return now }
+		token, _, err := sessions.Create("user-1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if _, err := sessions.Get(token); err != nil {
+			t.Error(err.Error())
+		}
+		now = now.Add(2 * time.Hour)
+		if _, err := sessions.Get(token); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("delete expired sessions", func(t *testing.T) {
+		sessions := NewSessionStore(time.Hour).(*sessionStore)
+		now := time.Now()
+		sessions.now = func() time.Time { return now }
+		sessions.Create("user-1")
+		now = now.Add(2 * time.Hour)
+		sessions.Create("user-2")
+
+		sessions.DeleteExpired()
+		if len(sessions.sessions) != 1 {
+			t.Errorf("uncorrect sessions count: %d", len(sessions.sessions))
+		}
+	})
+}
+
+func TestSessionAuthenticator(t *testing.T) {
+	users := newTestUserStore(t)
+	user, _ := users.Register("alice", "password123", "")
+	sessions := NewSessionStore(time.Hour)
+	token, session, _ := sessions.Create(user.ID)
+	authenticator := NewSessionAuthenticator(sessions, users)
+
+	tableTests := []struct {
+		name   string
+		method string
+		cookie string
+		csrf   string
+		err    error
+	}{
+		{name: "read without csrf", method: http.MethodGet, cookie: token},
+		{name: "write with csrf", method: http.MethodPost, cookie: token, csrf: session.CSRFToken},
+		{name: "write without csrf", method: http.MethodPost, cookie: token, err: ErrInvalidCSRF},
+		{name: "write with wrong csrf", method: http.MethodDelete, cookie: token, csrf: "guess", err: ErrInvalidCSRF},
+		{name: "unknown session", method: http.MethodGet, cookie: "stale", err: ErrNoCredentials},
+		{name: "no cookie", method: http.MethodGet, err: ErrNoCredentials},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+			if tt.csrf != "" {
+				req.Header.Set(CSRFHeaderName, tt.csrf)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if tt.err == nil && (principal == nil || principal.Kind != KindSession) {
+				t.Errorf("uncorrect principal: %v", principal)
+			}
+		})
+	}
+}
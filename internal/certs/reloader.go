@@ -0,0 +1,176 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificates = errors.New("no certificates found in CA bundle")
+
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	certMod   time.Time
+	keyMod    time.Time
+	caMod     time.Time
+}
+
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) SetClientCA(path string) error {
+	r.mu.Lock()
+	r.clientCAFile = path
+	r.mu.Unlock()
+	return r.Reload()
+}
+
+func (r *Reloader) Reload() error {
+	certMod, keyMod, caMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	r.mu.RLock()
+	clientCAFile := r.clientCAFile
+	r.mu.RUnlock()
+	var pool *x509.CertPool
+	if clientCAFile != "" {
+		if pool, err = LoadCAPool(clientCAFile); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.certMod = certMod
+	r.keyMod = keyMod
+	r.caMod = caMod
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reloader) modTimes() (time.Time, time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, err
+	}
+	r.mu.RLock()
+	clientCAFile := r.clientCAFile
+	r.mu.RUnlock()
+	if clientCAFile == "" {
+		return certInfo.ModTime(), keyInfo.ModTime(), time.Time{}, nil
+	}
+	caInfo, err := os.Stat(clientCAFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), caInfo.ModTime(), nil
+}
+
+func (r *Reloader) changed() bool {
+	certMod, keyMod, caMod, err := r.modTimes()
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod) || !caMod.Equal(r.caMod)
+}
+
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("TLS certificate reload failed, keeping previous one: %v", err)
+				continue
+			}
+			log.Printf("TLS certificates reloaded from %s", r.certFile)
+		}
+	}
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientCAs
+}
+
+func LoadCAPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", ErrNoCertificates, path)
+	}
+	return pool, nil
+}
+
+func ServerConfig(reloader *Reloader, clientCAFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+	if err := reloader.SetClientCA(clientCAFile); err != nil {
+		return nil, err
+	}
+	cfg.ClientCAs = reloader.ClientCAs()
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	var mu sync.Mutex
+	var current *tls.Config
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool := reloader.ClientCAs()
+		mu.Lock()
+		defer mu.Unlock()
+		if current == nil || current.ClientCAs != pool {
+			current = cfg.Clone()
+			current.ClientCAs = pool
+			current.GetConfigForClient = nil
+		}
+		return current, nil
+	}
+	return cfg, nil
+}
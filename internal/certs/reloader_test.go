@@ -0,0 +1,177 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCert(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:              []string{commonName},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	cert, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	t.Run("load certificate", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, t.TempDir(), "first")
+
+		reloader, err := NewReloader(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if name := commonName(t, reloader); name != "first" {
+			t.Errorf("uncorrect certificate: %s", name)
+		}
+	})
+
+	t.Run("reload changed certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writeCert(t, dir, "first")
+		reloader, err := NewReloader(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go reloader.Watch(ctx, 10*time.Millisecond)
+
+		writeCert(t, dir, "second")
+		future := time.Now().Add(time.Minute)
+		os.Chtimes(certFile, future, future)
+		deadline := time.Now().Add(5 * time.Second)
+		for commonName(t, reloader) != "second" {
+			if time.Now().After(deadline) {
+				t.Fatal("certificate was not reloaded")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("load missing files", func(t *testing.T) {
+		_, err := NewReloader("missing.crt", "missing.key")
+		if err == nil {
+			t.Error("expected error for missing files")
+		}
+	})
+}
+
+func TestServerConfig(t *testing.T) {
+	t.Run("mtls config", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writeCert(t, dir, "server")
+		reloader, _ := NewReloader(certFile, keyFile)
+
+		cfg, err := ServerConfig(reloader, certFile)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+			t.Errorf("uncorrect client auth: %v", cfg.ClientAuth)
+		}
+	})
+
+	t.Run("reload changed client CA", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, t.TempDir(), "server")
+		reloader, _ := NewReloader(certFile, keyFile)
+		caDir := t.TempDir()
+		caFile, _ := writeCert(t, caDir, "first-ca")
+		cfg, err := ServerConfig(reloader, caFile)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go reloader.Watch(ctx, 10*time.Millisecond)
+
+		writeCert(t, caDir, "second-ca")
+		future := time.Now().Add(time.Minute)
+		os.Chtimes(caFile, future, future)
+		data, _ := os.ReadFile(caFile)
+		block, _ := pem.Decode(data)
+		second, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		options := x509.VerifyOptions{KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			clientConfig, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			options.Roots = clientConfig.ClientCAs
+			if _, err := second.Verify(options); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("client CA was not reloaded")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("invalid CA bundle", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile, keyFile := writeCert(t, dir, "server")
+		reloader, _ := NewReloader(certFile, keyFile)
+
+		_, err := ServerConfig(reloader, keyFile)
+		if !errors.Is(err, ErrNoCertificates) {
+			t.Errorf("expected ErrNoCertificates, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAddr     = errors.New("invalid listen address")
+	ErrInvalidTimeout  = errors.New("invalid timeout")
+	ErrInvalidTLS      = errors.New("invalid TLS configuration")
+	ErrInvalidAuth     = errors.New("invalid auth configuration")
+	ErrInvalidTenancy  = errors.New("invalid tenancy configuration")
+	ErrInvalidLimits   = errors.New("invalid rate limit configuration")
+	ErrInvalidLogs     = errors.New("invalid access log configuration")
+	ErrInvalidFeatures = errors.New("invalid features configuration")
+	ErrInvalidCORS     = errors.New("invalid CORS configuration")
+)
+
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+type ServerConfig struct {
+	Addr            string   `json:"addr"`
+	ReadTimeout     Duration `json:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	ShutdownDelay   Duration `json:"shutdown_delay"`
+}
+
+type TLSConfig struct {
+	CertFile       string   `json:"cert_file"`
+	KeyFile        string   `json:"key_file"`
+	ClientCAFile   string   `json:"client_ca_file"`
+	ReloadInterval Duration `json:"reload_interval"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type JWTConfig struct {
+	Secret         string   `json:"secret"`
+	JWKSFile       string   `json:"jwks_file"`
+	Issuer         string   `json:"issuer"`
+	Audience       string   `json:"audience"`
+	Leeway         Duration `json:"leeway"`
+	ReloadInterval Duration `json:"reload_interval"`
+}
+
+func (j JWTConfig) Enabled() bool {
+	return j.Secret != "" || j.JWKSFile != ""
+}
+
+type SessionsConfig struct {
+	TTL              Duration `json:"ttl"`
+	CookieSecure     bool     `json:"cookie_secure"`
+	Registration     bool     `json:"registration"`
+	PBKDF2Iterations int      `json:"pbkdf2_iterations"`
+}
+
+type AuthConfig struct {
+	Enabled        bool           `json:"enabled"`
+	AdminKeySHA256 string         `json:"admin_key_sha256"`
+	JWT            JWTConfig      `json:"jwt"`
+	Sessions       SessionsConfig `json:"sessions"`
+}
+
+type AuthorizationConfig struct {
+	Policies map[string][]string `json:"policies,omitempty"`
+}
+
+type WorkflowConfig struct {
+	Statuses    []string            `json:"statuses,omitempty"`
+	Initial     string              `json:"initial,omitempty"`
+	Done        string              `json:"done,omitempty"`
+	Terminal    []string            `json:"terminal,omitempty"`
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+type TenancyConfig struct {
+	Header        string         `json:"header"`
+	DefaultTenant string         `json:"default_tenant"`
+	Tenants       []string       `json:"tenants,omitempty"`
+	MaxTasks      int            `json:"max_tasks"`
+	Quotas        map[string]int `json:"quotas,omitempty"`
+}
+
+func (t TenancyConfig) KnownTenants() []string {
+	tenants := []string{t.DefaultTenant}
+	for _, tenantID := range t.Tenants {
+		if !slices.Contains(tenants, tenantID) {
+			tenants = append(tenants, tenantID)
+		}
+	}
+	return tenants
+}
+
+func (t TenancyConfig) Quota(tenantID string) uint64 {
+	if quota, ok := t.Quotas[tenantID]; ok {
+		return uint64(quota)
+	}
+	return uint64(t.MaxTasks)
+}
+
+type RateLimitConfig struct {
+	Enabled        bool     `json:"enabled"`
+	ReadLimit      int      `json:"read_limit"`
+	WriteLimit     int      `json:"write_limit"`
+	Window         Duration `json:"window"`
+	IdleTimeout    Duration `json:"idle_timeout"`
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+}
+
+type AccessLogConfig struct {
+	File      string   `json:"file"`
+	Format    string   `json:"format"`
+	MaxSizeMB int      `json:"max_size_mb"`
+	MaxAge    Duration `json:"max_age"`
+	Compress  bool     `json:"compress"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins,omitempty"`
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           Duration `json:"max_age"`
+}
+
+func (c CORSConfig) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+type FeaturesConfig struct {
+	RequestLogging     bool   `json:"request_logging"`
+	PanicReportFile    string `json:"panic_report_file,omitempty"`
+	Compression        bool   `json:"compression"`
+	CompressionMinSize int    `json:"compression_min_size"`
+}
+
+type Config struct {
+	Server    ServerConfig        `json:"server"`
+	TLS       TLSConfig           `json:"tls"`
+	Auth      AuthConfig          `json:"auth"`
+	Authz     AuthorizationConfig `json:"authorization"`
+	Workflow  WorkflowConfig      `json:"workflow"`
+	Tenancy   TenancyConfig       `json:"tenancy"`
+	RateLimit RateLimitConfig     `json:"rate_limit"`
+	AccessLog AccessLogConfig     `json:"access_log"`
+	CORS      CORSConfig          `json:"cors"`
+	Features  FeaturesConfig      `json:"features"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     Duration{15 * time.Second},
+			WriteTimeout:    Duration{15 * time.Second},
+			IdleTimeout:     Duration{60 * time.Second},
+			ShutdownTimeout: Duration{10 * time.Second},
+		},
+		TLS: TLSConfig{
+			ReloadInterval: Duration{30 * time.Second},
+		},
+		Auth: AuthConfig{
+			JWT: JWTConfig{
+				Leeway:         Duration{30 * time.Second},
+				ReloadInterval: Duration{time.Minute},
+			},
+			Sessions: SessionsConfig{
+				TTL:              Duration{24 * time.Hour},
+				CookieSecure:     true,
+				Registration:     true,
+				PBKDF2Iterations: 600_000,
+			},
+		},
+		Tenancy: TenancyConfig{
+			Header:        "X-Tenant-ID",
+			DefaultTenant: "default",
+		},
+		RateLimit: RateLimitConfig{
+			ReadLimit:   600,
+			WriteLimit:  120,
+			Window:      Duration{time.Minute},
+			IdleTimeout: Duration{10 * time.Minute},
+		},
+		AccessLog: AccessLogConfig{
+			Format:    "combined",
+			MaxSizeMB: 100,
+			MaxAge:    Duration{24 * time.Hour},
+			Compress:  true,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-CSRF-Token", "X-Request-ID", "X-Tenant-ID", "X-Timezone"},
+			ExposedHeaders: []string{"ETag", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "X-Request-ID", "X-Status-Counts"},
+			MaxAge:         Duration{10 * time.Minute},
+		},
+		Features: FeaturesConfig{
+			RequestLogging:     true,
+			Compression:        true,
+			CompressionMinSize: 1024,
+		},
+	}
+}
+
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("parse config file %s: unexpected data after the top-level object", path)
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidAddr, c.Server.Addr, err)
+	}
+	timeouts := map[string]time.Duration{
+		"read_timeout":  c.Server.ReadTimeout.Duration,
+		"write_timeout": c.Server.WriteTimeout.Duration,
+		"idle_timeout":  c.Server.IdleTimeout.Duration,
+	}
+	for name, timeout := range timeouts {
+		if timeout < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidTimeout, name)
+		}
+	}
+	if c.Server.ShutdownTimeout.Duration <= 0 {
+		return fmt.Errorf("%w: shutdown_timeout must be positive", ErrInvalidTimeout)
+	}
+	if c.Server.ShutdownDelay.Duration < 0 {
+		return fmt.Errorf("%w: shutdown_delay must not be negative", ErrInvalidTimeout)
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("%w: cert_file and key_file must be set together", ErrInvalidTLS)
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		return fmt.Errorf("%w: client_ca_file requires cert_file and key_file", ErrInvalidTLS)
+	}
+	if c.TLS.Enabled() && c.TLS.ReloadInterval.Duration <= 0 {
+		return fmt.Errorf("%w: reload_interval must be positive", ErrInvalidTLS)
+	}
+	if c.Auth.Enabled && c.Auth.AdminKeySHA256 == "" && !c.Auth.JWT.Enabled() {
+		return fmt.Errorf("%w: admin_key_sha256 or jwt is required when auth is enabled", ErrInvalidAuth)
+	}
+	if c.Auth.JWT.Secret != "" && c.Auth.JWT.JWKSFile != "" {
+		return fmt.Errorf("%w: jwt secret and jwks_file are mutually exclusive", ErrInvalidAuth)
+	}
+	if c.Auth.JWT.Leeway.Duration < 0 || c.Auth.JWT.ReloadInterval.Duration <= 0 {
+		return fmt.Errorf("%w: jwt leeway must not be negative and reload_interval must be positive", ErrInvalidAuth)
+	}
+	if c.Auth.Sessions.TTL.Duration <= 0 {
+		return fmt.Errorf("%w: sessions ttl must be positive", ErrInvalidAuth)
+	}
+	if c.Auth.Sessions.PBKDF2Iterations < 10_000 {
+		return fmt.Errorf("%w: pbkdf2_iterations must be at least 10000", ErrInvalidAuth)
+	}
+	if c.Tenancy.Header == "" || c.Tenancy.DefaultTenant == "" {
+		return fmt.Errorf("%w: header and default_tenant must be set", ErrInvalidTenancy)
+	}
+	if c.Tenancy.MaxTasks < 0 {
+		return fmt.Errorf("%w: max_tasks must not be negative", ErrInvalidTenancy)
+	}
+	for _, tenantID := range c.Tenancy.Tenants {
+		if strings.TrimSpace(tenantID) == "" {
+			return fmt.Errorf("%w: tenant names must not be empty", ErrInvalidTenancy)
+		}
+	}
+	for tenantID, quota := range c.Tenancy.Quotas {
+		if quota < 0 {
+			return fmt.Errorf("%w: quota for %s must not be negative", ErrInvalidTenancy, tenantID)
+		}
+		if !slices.Contains(c.Tenancy.KnownTenants(), tenantID) {
+			return fmt.Errorf("%w: quota for unknown tenant %s", ErrInvalidTenancy, tenantID)
+		}
+	}
+	if c.RateLimit.Enabled {
+		if c.RateLimit.ReadLimit <= 0 || c.RateLimit.WriteLimit <= 0 {
+			return fmt.Errorf("%w: read_limit and write_limit must be positive", ErrInvalidLimits)
+		}
+		if c.RateLimit.Window.Duration <= 0 || c.RateLimit.IdleTimeout.Duration <= 0 {
+			return fmt.Errorf("%w: window and idle_timeout must be positive", ErrInvalidLimits)
+		}
+		for _, proxy := range c.RateLimit.TrustedProxies {
+			if _, err := netip.ParsePrefix(proxy); err != nil {
+				if _, err := netip.ParseAddr(proxy); err != nil {
+					return fmt.Errorf("%w: trusted proxy %q is not an IP or CIDR", ErrInvalidLimits, proxy)
+				}
+			}
+		}
+	}
+	if c.Features.CompressionMinSize < 0 {
+		return fmt.Errorf("%w: compression_min_size must not be negative", ErrInvalidFeatures)
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			return fmt.Errorf("%w: allow_credentials cannot be used with origin *", ErrInvalidCORS)
+		}
+		if origin != "*" && !strings.Contains(origin, "://") {
+			return fmt.Errorf("%w: origin %q must include a scheme", ErrInvalidCORS, origin)
+		}
+	}
+	if c.CORS.MaxAge.Duration < 0 {
+		return fmt.Errorf("%w: max_age must not be negative", ErrInvalidCORS)
+	}
+	switch c.AccessLog.Format {
+	case "common", "combined", "json":
+	default:
+		return fmt.Errorf("%w: format must be common, combined or json", ErrInvalidLogs)
+	}
+	if c.AccessLog.MaxSizeMB < 0 || c.AccessLog.MaxAge.Duration < 0 {
+		return fmt.Errorf("%w: max_size_mb and max_age must not be negative", ErrInvalidLogs)
+	}
+	return nil
+}
+
+func (c *Config) String() string {
+	redacted := *c
+	if redacted.Auth.JWT.Secret != "" {
+		redacted.Auth.JWT.Secret = "******"
+	}
+	data, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
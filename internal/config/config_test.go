@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestConfigLoad(t *testing.T) {
+	t.Run("load defaults", func(t *testing.T) {
+		cfg, opts, err := Load(nil, envFrom(nil))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if cfg.Server.Addr != ":8080" {
+			t.Errorf("uncorrect addr: %s", cfg.Server.Addr)
+		}
+		if opts.PrintConfig {
+			t.Error("print-config must be disabled by default")
+		}
+	})
+
+	t.Run("load with precedence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		data := `{"server":{"addr":":7000","read_timeout":"1s","write_timeout":"2s"}}`
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err.Error())
+		}
+		env := envFrom(map[string]string{
+			"HTTP_SERVER_CONFIG":        path,
+			"HTTP_SERVER_READ_TIMEOUT":  "3s",
+			"HTTP_SERVER_WRITE_TIMEOUT": "4s",
+		})
+
+		cfg, _, err := Load([]string{"-write-timeout", "5s", "--print-config"}, env)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if cfg.Server.Addr != ":7000" {
+			t.Errorf("expected addr from file, got %s", cfg.Server.Addr)
+		}
+		if cfg.Server.ReadTimeout.Duration != 3*time.Second {
+			t.Errorf("expected read timeout from env, got %s", cfg.Server.ReadTimeout)
+		}
+		if cfg.Server.WriteTimeout.Duration != 5*time.Second {
+			t.Errorf("expected write timeout from flag, got %s", cfg.Server.WriteTimeout)
+		}
+	})
+
+	t.Run("load unknown file option", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		data := `{"server":{"addr":":7000","read_timout":"1s"}}`
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, _, err := Load(nil, envFrom(map[string]string{"HTTP_SERVER_CONFIG": path}))
+		if err == nil || !strings.Contains(err.Error(), "read_timout") {
+			t.Errorf("expected unknown field error, got %v", err)
+		}
+	})
+
+	t.Run("load invalid values", func(t *testing.T) {
+		_, _, err := Load([]string{"-addr", "8080"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidAddr) {
+			t.Errorf("expected ErrInvalidAddr, got %v", err)
+		}
+		_, _, err = Load([]string{"-shutdown-timeout", "0s"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidTimeout) {
+			t.Errorf("expected ErrInvalidTimeout, got %v", err)
+		}
+		_, _, err = Load([]string{"-shutdown-delay", "-1s"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidTimeout) {
+			t.Errorf("expected ErrInvalidTimeout, got %v", err)
+		}
+		cfg := Default()
+		cfg.Tenancy.Quotas = map[string]int{"team-a": 10}
+		if err = cfg.Validate(); !errors.Is(err, ErrInvalidTenancy) {
+			t.Errorf("expected ErrInvalidTenancy, got %v", err)
+		}
+		_, _, err = Load([]string{"-tls-cert", "server.crt"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidTLS) {
+			t.Errorf("expected ErrInvalidTLS, got %v", err)
+		}
+		_, _, err = Load([]string{"-tls-client-ca", "ca.crt"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidTLS) {
+			t.Errorf("expected ErrInvalidTLS, got %v", err)
+		}
+		_, _, err = Load([]string{"-auth"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidAuth) {
+			t.Errorf("expected ErrInvalidAuth, got %v", err)
+		}
+		_, _, err = Load([]string{"-jwt-secret", "s", "-jwt-jwks-file", "keys.json"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidAuth) {
+			t.Errorf("expected ErrInvalidAuth, got %v", err)
+		}
+		_, _, err = Load([]string{"-rate-limit", "-rate-limit-trusted-proxies", "10.0.0.0/8,proxy"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidLimits) {
+			t.Errorf("expected ErrInvalidLimits, got %v", err)
+		}
+		_, _, err = Load([]string{"-access-log-format", "xml"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidLogs) {
+			t.Errorf("expected ErrInvalidLogs, got %v", err)
+		}
+		_, _, err = Load([]string{"-cors-origins", "*", "-cors-credentials"}, envFrom(nil))
+		if !errors.Is(err, ErrInvalidCORS) {
+			t.Errorf("expected ErrInvalidCORS, got %v", err)
+		}
+		_, _, err = Load(nil, envFrom(map[string]string{"HTTP_SERVER_IDLE_TIMEOUT": "soon"}))
+		if err == nil {
+			t.Error("expected error for unparsable env value")
+		}
+	})
+}
+
+func TestConfigString(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWT.Secret = "top-secret"
+
+	if strings.Contains(cfg.String(), "top-secret") {
+		t.Error("secret must be redacted")
+	}
+	if cfg.Auth.JWT.Secret != "top-secret" {
+		t.Error("redaction must not modify config")
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "HTTP_SERVER_"
+
+type option struct {
+	name   string
+	usage  string
+	isBool bool
+	set    func(c *Config, value string) error
+}
+
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+func stringOption(name, usage string, field func(c *Config) *string) option {
+	return option{name: name, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}}
+}
+
+func durationOption(name, usage string, field func(c *Config) *Duration) option {
+	return option{name: name, usage: usage, set: func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field(c).Duration = d
+		return nil
+	}}
+}
+
+func intOption(name, usage string, field func(c *Config) *int) option {
+	return option{name: name, usage: usage, set: func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}}
+}
+
+func listOption(name, usage string, field func(c *Config) *[]string) option {
+	return option{name: name, usage: usage, set: func(c *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}}
+}
+
+func boolOption(name, usage string, field func(c *Config) *bool) option {
+	return option{name: name, usage: usage, isBool: true, set: func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}}
+}
+
+var options = []option{
+	stringOption("addr", "listen address", func(c *Config) *string { return &c.Server.Addr }),
+	durationOption("read-timeout", "maximum duration for reading the entire request", func(c *Config) *Duration { return &c.Server.ReadTimeout }),
+	durationOption("write-timeout", "maximum duration before timing out writes of the response", func(c *Config) *Duration { return &c.Server.WriteTimeout }),
+	durationOption("idle-timeout", "maximum time to wait for the next request on keep-alive connections", func(c *Config) *Duration { return &c.Server.IdleTimeout }),
+	durationOption("shutdown-timeout", "time to drain in-flight requests on shutdown", func(c *Config) *Duration { return &c.Server.ShutdownTimeout }),
+	durationOption("shutdown-delay", "time /readyz reports draining before the listener closes", func(c *Config) *Duration { return &c.Server.ShutdownDelay }),
+	stringOption("tls-cert", "path to TLS certificate, enables HTTPS", func(c *Config) *string { return &c.TLS.CertFile }),
+	stringOption("tls-key", "path to TLS private key", func(c *Config) *string { return &c.TLS.KeyFile }),
+	stringOption("tls-client-ca", "path to CA bundle for client certificates, enables mTLS", func(c *Config) *string { return &c.TLS.ClientCAFile }),
+	durationOption("tls-reload-interval", "how often certificate files are checked for changes", func(c *Config) *Duration { return &c.TLS.ReloadInterval }),
+	boolOption("auth", "require authentication for the API", func(c *Config) *bool { return &c.Auth.Enabled }),
+	stringOption("auth-admin-key-sha256", "hex SHA-256 of the bootstrap admin API key", func(c *Config) *string { return &c.Auth.AdminKeySHA256 }),
+	stringOption("jwt-secret", "HS256 shared secret for JWT verification", func(c *Config) *string { return &c.Auth.JWT.Secret }),
+	stringOption("jwt-jwks-file", "path to JWKS file with JWT verification keys", func(c *Config) *string { return &c.Auth.JWT.JWKSFile }),
+	stringOption("jwt-issuer", "required JWT iss claim", func(c *Config) *string { return &c.Auth.JWT.Issuer }),
+	stringOption("jwt-audience", "required JWT aud claim", func(c *Config) *string { return &c.Auth.JWT.Audience }),
+	durationOption("jwt-leeway", "allowed clock skew for exp and nbf", func(c *Config) *Duration { return &c.Auth.JWT.Leeway }),
+	durationOption("jwt-reload-interval", "how often the JWKS file is checked for changes", func(c *Config) *Duration { return &c.Auth.JWT.ReloadInterval }),
+	durationOption("session-ttl", "lifetime of browser login sessions", func(c *Config) *Duration { return &c.Auth.Sessions.TTL }),
+	boolOption("session-cookie-secure", "mark session cookies Secure (HTTPS only)", func(c *Config) *bool { return &c.Auth.Sessions.CookieSecure }),
+	boolOption("registration", "allow self-service user registration", func(c *Config) *bool { return &c.Auth.Sessions.Registration }),
+	intOption("pbkdf2-iterations", "PBKDF2-SHA256 iterations for password hashing", func(c *Config) *int { return &c.Auth.Sessions.PBKDF2Iterations }),
+	stringOption("tenant-header", "header used to select a tenant", func(c *Config) *string { return &c.Tenancy.Header }),
+	stringOption("default-tenant", "tenant used when none is given", func(c *Config) *string { return &c.Tenancy.DefaultTenant }),
+	listOption("tenants", "comma-separated tenants served besides the default one", func(c *Config) *[]string { return &c.Tenancy.Tenants }),
+	intOption("tenant-max-tasks", "maximum tasks per tenant, 0 for unlimited", func(c *Config) *int { return &c.Tenancy.MaxTasks }),
+	boolOption("rate-limit", "limit request rate per client", func(c *Config) *bool { return &c.RateLimit.Enabled }),
+	intOption("rate-limit-read", "read requests allowed per client per window", func(c *Config) *int { return &c.RateLimit.ReadLimit }),
+	intOption("rate-limit-write", "write requests allowed per client per window", func(c *Config) *int { return &c.RateLimit.WriteLimit }),
+	durationOption("rate-limit-window", "window in which the rate limits refill", func(c *Config) *Duration { return &c.RateLimit.Window }),
+	durationOption("rate-limit-idle-timeout", "how long unused client buckets are kept", func(c *Config) *Duration { return &c.RateLimit.IdleTimeout }),
+	listOption("rate-limit-trusted-proxies", "comma-separated proxy IPs or CIDRs allowed to set X-Forwarded-For", func(c *Config) *[]string { return &c.RateLimit.TrustedProxies }),
+	stringOption("access-log", "path to access log file", func(c *Config) *string { return &c.AccessLog.File }),
+	stringOption("access-log-format", "access log format: common, combined or json", func(c *Config) *string { return &c.AccessLog.Format }),
+	intOption("access-log-max-size", "rotate the access log after this many megabytes, 0 to disable", func(c *Config) *int { return &c.AccessLog.MaxSizeMB }),
+	durationOption("access-log-max-age", "rotate the access log after this duration, 0 to disable", func(c *Config) *Duration { return &c.AccessLog.MaxAge }),
+	boolOption("access-log-compress", "gzip rotated access logs", func(c *Config) *bool { return &c.AccessLog.Compress }),
+	boolOption("request-logging", "log every request", func(c *Config) *bool { return &c.Features.RequestLogging }),
+	listOption("cors-origins", "comma-separated allowed CORS origins, * and https://*.example.com supported", func(c *Config) *[]string { return &c.CORS.AllowedOrigins }),
+	listOption("cors-methods", "comma-separated methods allowed in CORS requests", func(c *Config) *[]string { return &c.CORS.AllowedMethods }),
+	listOption("cors-headers", "comma-separated request headers allowed in CORS requests", func(c *Config) *[]string { return &c.CORS.AllowedHeaders }),
+	listOption("cors-expose-headers", "comma-separated response headers exposed to CORS requests", func(c *Config) *[]string { return &c.CORS.ExposedHeaders }),
+	boolOption("cors-credentials", "allow cookies and credentials in CORS requests", func(c *Config) *bool { return &c.CORS.AllowCredentials }),
+	durationOption("cors-max-age", "how long browsers may cache preflight responses", func(c *Config) *Duration { return &c.CORS.MaxAge }),
+	boolOption("compression", "compress responses with gzip or deflate", func(c *Config) *bool { return &c.Features.Compression }),
+	intOption("compression-min-size", "minimum response size in bytes to compress", func(c *Config) *int { return &c.Features.CompressionMinSize }),
+	stringOption("panic-report-file", "append recovered panics as JSON lines to this file", func(c *Config) *string { return &c.Features.PanicReportFile }),
+}
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+type Options struct {
+	PrintConfig bool
+}
+
+func Load(args []string, getenv func(string) string) (*Config, Options, error) {
+	var opts Options
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to JSON config file (env "+envName("config")+")")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration and exit")
+	values := make(map[string]*flagValue, len(options))
+	for _, o := range options {
+		values[o.name] = &flagValue{isBool: o.isBool}
+		fs.Var(values[o.name], o.name, o.usage+" (env "+envName(o.name)+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, err
+	}
+
+	cfg := Default()
+	path := *configPath
+	if path == "" {
+		path = getenv(envName("config"))
+	}
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return nil, opts, err
+		}
+	}
+	for _, o := range options {
+		if value := getenv(envName(o.name)); value != "" {
+			if err := o.set(cfg, value); err != nil {
+				return nil, opts, fmt.Errorf("env %s: %w", envName(o.name), err)
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, o := range options {
+			if o.name == f.Name && flagErr == nil {
+				if err := o.set(cfg, values[o.name].value); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %w", o.name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, opts, flagErr
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, err
+	}
+	return cfg, opts, nil
+}
@@ -0,0 +1,110 @@
+package inmemory
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"webServerEx/internal/entity"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrCommentIsNil    = errors.New("comment is nil")
+)
+
+func (ts *TasksStorage) removeComments(taskID uint64) {
+	for _, id := range ts.taskComments[taskID] {
+		delete(ts.comments, id)
+	}
+	delete(ts.taskComments, taskID)
+}
+
+func (ts *TasksStorage) AddComment(comment *entity.Comment) error {
+	if comment == nil {
+		return ErrCommentIsNil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	if ts.nextCommentID == math.MaxUint64 {
+		return ErrTooManyTasks
+	}
+	if _, ok := ts.data[comment.TaskID]; !ok {
+		return ErrTaskNotFound
+	}
+	comment.ID = ts.nextCommentID
+	ts.comments[comment.ID] = comment
+	ts.taskComments[comment.TaskID] = append(ts.taskComments[comment.TaskID], comment.ID)
+	ts.nextCommentID++
+	return nil
+}
+
+func (ts *TasksStorage) GetComment(id uint64) (*entity.Comment, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if comment, ok := ts.comments[id]; ok {
+		return comment, nil
+	}
+	return nil, ErrCommentNotFound
+}
+
+func (ts *TasksStorage) ListComments(taskID uint64, offset, limit int) ([]*entity.Comment, int, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.data[taskID]; !ok {
+		return nil, 0, ErrTaskNotFound
+	}
+	ids := ts.taskComments[taskID]
+	total := len(ids)
+	start := min(max(offset, 0), total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	comments := make([]*entity.Comment, 0, end-start)
+	for _, id := range ids[start:end] {
+		comments = append(comments, ts.comments[id])
+	}
+	return comments, total, nil
+}
+
+func (ts *TasksStorage) UpdateComment(id uint64, comment *entity.Comment) error {
+	if comment == nil {
+		return ErrCommentIsNil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	current, ok := ts.comments[id]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	comment.ID = id
+	comment.TaskID = current.TaskID
+	ts.comments[id] = comment
+	return nil
+}
+
+func (ts *TasksStorage) DeleteComment(id uint64) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	comment, ok := ts.comments[id]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	delete(ts.comments, id)
+	ts.taskComments[comment.TaskID] = slices.DeleteFunc(ts.taskComments[comment.TaskID], func(commentID uint64) bool {
+		return commentID == id
+	})
+	if len(ts.taskComments[comment.TaskID]) == 0 {
+		delete(ts.taskComments, comment.TaskID)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package inmemory
+
+import (
+	"errors"
+	"slices"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+var (
+	ErrDependencyCycle    = errors.New("dependency would create a cycle")
+	ErrDependencyNotFound = errors.New("dependency not found")
+)
+
+func (ts *TasksStorage) indexBlockers(task *entity.Task) {
+	for _, blocker := range task.BlockedBy {
+		ids, ok := ts.blocks[blocker]
+		if !ok {
+			ids = make(map[uint64]struct{})
+			ts.blocks[blocker] = ids
+		}
+		ids[task.ID] = struct{}{}
+	}
+}
+
+func (ts *TasksStorage) unindexBlockers(task *entity.Task) {
+	for _, blocker := range task.BlockedBy {
+		delete(ts.blocks[blocker], task.ID)
+		if len(ts.blocks[blocker]) == 0 {
+			delete(ts.blocks, blocker)
+		}
+	}
+}
+
+func (ts *TasksStorage) dependsOn(id, target uint64) bool {
+	visited := make(map[uint64]bool)
+	stack := []uint64{id}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == target {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		if task, ok := ts.data[current]; ok {
+			stack = append(stack, task.BlockedBy...)
+		}
+	}
+	return false
+}
+
+func (ts *TasksStorage) AddDependency(id, blockerID uint64, updatedAt time.Time) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	current, ok := ts.data[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if _, ok := ts.data[blockerID]; !ok {
+		return ErrTaskNotFound
+	}
+	if slices.Contains(current.BlockedBy, blockerID) {
+		return nil
+	}
+	if ts.dependsOn(blockerID, id) {
+		return ErrDependencyCycle
+	}
+	ts.replace(current, func(task *entity.Task) {
+		task.BlockedBy = append(slices.Clone(task.BlockedBy), blockerID)
+		slices.Sort(task.BlockedBy)
+		task.UpdatedAt = updatedAt
+	})
+	return nil
+}
+
+func (ts *TasksStorage) RemoveDependency(id, blockerID uint64, updatedAt time.Time) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	current, ok := ts.data[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	index := slices.Index(current.BlockedBy, blockerID)
+	if index < 0 {
+		return ErrDependencyNotFound
+	}
+	ts.replace(current, func(task *entity.Task) {
+		task.BlockedBy = slices.Delete(slices.Clone(task.BlockedBy), index, index+1)
+		task.UpdatedAt = updatedAt
+	})
+	return nil
+}
+
+func (ts *TasksStorage) Dependents(id uint64) ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.data[id]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	data := make([]*entity.Task, 0, len(ts.blocks[id]))
+	for dependentID := range ts.blocks[id] {
+		data = append(data, ts.data[dependentID])
+	}
+	return data, nil
+}
@@ -0,0 +1,131 @@
+package inmemory
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectIsNil    = errors.New("project is nil")
+	ErrProjectNotEmpty = errors.New("project is not empty")
+	ErrInboxProject    = errors.New("inbox project cannot be deleted")
+)
+
+func (ts *TasksStorage) indexProject(task *entity.Task) {
+	ids, ok := ts.projectTasks[task.ProjectID]
+	if !ok {
+		ids = make(map[uint64]struct{})
+		ts.projectTasks[task.ProjectID] = ids
+	}
+	ids[task.ID] = struct{}{}
+}
+
+func (ts *TasksStorage) unindexProject(task *entity.Task) {
+	delete(ts.projectTasks[task.ProjectID], task.ID)
+	if len(ts.projectTasks[task.ProjectID]) == 0 {
+		delete(ts.projectTasks, task.ProjectID)
+	}
+}
+
+func (ts *TasksStorage) AddProject(project *entity.Project) error {
+	if project == nil {
+		return ErrProjectIsNil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	project.ID = ts.nextProjectID
+	ts.projects[project.ID] = project
+	ts.nextProjectID++
+	return nil
+}
+
+func (ts *TasksStorage) GetProject(id uint64) (*entity.Project, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if project, ok := ts.projects[id]; ok {
+		return project, nil
+	}
+	return nil, ErrProjectNotFound
+}
+
+func (ts *TasksStorage) GetProjects() ([]*entity.Project, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	projects := make([]*entity.Project, 0, len(ts.projects))
+	for _, project := range ts.projects {
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+func (ts *TasksStorage) UpdateProject(id uint64, project *entity.Project) error {
+	if project == nil {
+		return ErrProjectIsNil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	if _, ok := ts.projects[id]; !ok {
+		return ErrProjectNotFound
+	}
+	project.ID = id
+	ts.projects[id] = project
+	return nil
+}
+
+func (ts *TasksStorage) DeleteProject(id uint64, policy string, updatedAt time.Time) (int, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return 0, ErrClosed
+	}
+	if id == entity.InboxProjectID {
+		return 0, ErrInboxProject
+	}
+	if _, ok := ts.projects[id]; !ok {
+		return 0, ErrProjectNotFound
+	}
+	ids := ts.projectTasks[id]
+	affected := len(ids)
+	switch policy {
+	case entity.ProjectDeleteCascade:
+		for _, taskID := range slices.Collect(maps.Keys(ids)) {
+			ts.remove(taskID)
+		}
+	case entity.ProjectDeleteInbox:
+		for taskID := range ids {
+			ts.replace(ts.data[taskID], func(task *entity.Task) {
+				task.ProjectID = entity.InboxProjectID
+				task.UpdatedAt = updatedAt
+			})
+		}
+	default:
+		if affected > 0 {
+			return 0, ErrProjectNotEmpty
+		}
+	}
+	delete(ts.projects, id)
+	return affected, nil
+}
+
+func (ts *TasksStorage) FindByProject(id uint64) ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.projects[id]; !ok {
+		return nil, ErrProjectNotFound
+	}
+	data := make([]*entity.Task, 0, len(ts.projectTasks[id]))
+	for taskID := range ts.projectTasks[id] {
+		data = append(data, ts.data[taskID])
+	}
+	return data, nil
+}
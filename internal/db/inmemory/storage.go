@@ -1,9 +1,12 @@
 package inmemory
 
 import (
+	"context"
 	"errors"
 	"math"
+	"slices"
 	"sync"
+	"time"
 	"webServerEx/internal/entity"
 )
 
@@ -12,20 +15,91 @@ var (
 	ErrStorageEmpty = errors.New("tasks storage is empty")
 	ErrTaskIsNil    = errors.New("task is nil")
 	ErrTooManyTasks = errors.New("task is too many")
+	ErrClosed       = errors.New("tasks storage is closed")
+	ErrQuota        = errors.New("tasks quota exceeded")
+	ErrTagNotFound  = errors.New("tag not found")
 )
 
 type TasksStorage struct {
-	length    uint64
-	currentId uint64
-	data      map[uint64]*entity.Task
-	mu        sync.RWMutex
+	length        uint64
+	currentId     uint64
+	data          map[uint64]*entity.Task
+	tags          map[string]map[uint64]struct{}
+	projects      map[uint64]*entity.Project
+	projectTasks  map[uint64]map[uint64]struct{}
+	children      map[uint64]map[uint64]struct{}
+	blocks        map[uint64]map[uint64]struct{}
+	comments      map[uint64]*entity.Comment
+	taskComments  map[uint64][]uint64
+	nextProjectID uint64
+	nextCommentID uint64
+	maxTasks      uint64
+	closed        bool
+	mu            sync.RWMutex
 }
 
 func NewStorage() *TasksStorage {
 	return &TasksStorage{
-		length:    0,
-		currentId: 0,
-		data:      make(map[uint64]*entity.Task),
+		length:        0,
+		currentId:     0,
+		data:          make(map[uint64]*entity.Task),
+		tags:          make(map[string]map[uint64]struct{}),
+		projects:      map[uint64]*entity.Project{entity.InboxProjectID: entity.NewInbox()},
+		projectTasks:  make(map[uint64]map[uint64]struct{}),
+		children:      make(map[uint64]map[uint64]struct{}),
+		blocks:        make(map[uint64]map[uint64]struct{}),
+		comments:      make(map[uint64]*entity.Comment),
+		taskComments:  make(map[uint64][]uint64),
+		nextProjectID: entity.InboxProjectID + 1,
+	}
+}
+
+func (ts *TasksStorage) index(task *entity.Task) {
+	ts.indexTags(task)
+	ts.indexProject(task)
+	ts.indexParent(task)
+	ts.indexBlockers(task)
+}
+
+func (ts *TasksStorage) unindex(task *entity.Task) {
+	ts.unindexTags(task)
+	ts.unindexProject(task)
+	ts.unindexParent(task)
+	ts.unindexBlockers(task)
+}
+
+func (ts *TasksStorage) remove(id uint64) {
+	task := ts.data[id]
+	ts.unindex(task)
+	ts.removeComments(id)
+	delete(ts.data, id)
+	ts.length--
+	for dependentID := range ts.blocks[id] {
+		ts.replace(ts.data[dependentID], func(dependent *entity.Task) {
+			dependent.BlockedBy = slices.DeleteFunc(slices.Clone(dependent.BlockedBy), func(blocker uint64) bool {
+				return blocker == id
+			})
+		})
+	}
+}
+
+func (ts *TasksStorage) indexTags(task *entity.Task) {
+	for _, tag := range task.Tags {
+		ids, ok := ts.tags[tag]
+		if !ok {
+			ids = make(map[uint64]struct{})
+			ts.tags[tag] = ids
+		}
+		ids[task.ID] = struct{}{}
+	}
+}
+
+func (ts *TasksStorage) unindexTags(task *entity.Task) {
+	for _, tag := range task.Tags {
+		delete(ts.tags[tag], task.ID)
+		if len(ts.tags[tag]) == 0 {
+			delete(ts.tags, tag)
+		}
 	}
 }
 
@@ -33,15 +107,30 @@ func (ts *TasksStorage) Add(task *entity.Task) error {
 	if task == nil {
 		return ErrTaskIsNil
 	}
-	if ts.length == math.MaxUint64 {
-		return ErrTooManyTasks
+	ts.mu.Lock()
+	if ts.closed {
+		ts.mu.Unlock()
+		return ErrClosed
 	}
-	if ts.currentId == math.MaxUint64 {
+	if ts.length == math.MaxUint64 || ts.currentId == math.MaxUint64 {
+		ts.mu.Unlock()
 		return ErrTooManyTasks
 	}
-	ts.mu.Lock()
+	if ts.maxTasks > 0 && ts.length >= ts.maxTasks {
+		ts.mu.Unlock()
+		return ErrQuota
+	}
+	if _, ok := ts.projects[task.ProjectID]; !ok {
+		ts.mu.Unlock()
+		return ErrProjectNotFound
+	}
+	if task.ParentID != nil && ts.data[*task.ParentID] == nil {
+		ts.mu.Unlock()
+		return ErrParentNotFound
+	}
 	ts.data[ts.currentId] = task
 	task.ID = ts.currentId
+	ts.index(task)
 	ts.currentId++
 	ts.length++
 	ts.mu.Unlock()
@@ -51,23 +140,37 @@ func (ts *TasksStorage) Add(task *entity.Task) error {
 func (ts *TasksStorage) Delete(id uint64) error {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
 	if _, ok := ts.data[id]; ok {
-		delete(ts.data, id)
-		ts.length--
+		for _, descendant := range ts.descendants(id) {
+			ts.remove(descendant.ID)
+		}
+		ts.remove(id)
 		return nil
 	}
 	return ErrTaskNotFound
 }
 
 func (ts *TasksStorage) DeleteAll() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
 	if ts.length == 0 {
 		return ErrStorageEmpty
 	}
-	ts.mu.Lock()
 	ts.data = make(map[uint64]*entity.Task)
+	ts.tags = make(map[string]map[uint64]struct{})
+	ts.projectTasks = make(map[uint64]map[uint64]struct{})
+	ts.children = make(map[uint64]map[uint64]struct{})
+	ts.blocks = make(map[uint64]map[uint64]struct{})
+	ts.comments = make(map[uint64]*entity.Comment)
+	ts.taskComments = make(map[uint64][]uint64)
 	ts.length = 0
 	ts.currentId = 0
-	ts.mu.Unlock()
 	return nil
 }
 
@@ -77,9 +180,20 @@ func (ts *TasksStorage) Update(id uint64, task *entity.Task) error {
 	}
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
-	if _, ok := ts.data[id]; ok {
+	if ts.closed {
+		return ErrClosed
+	}
+	if current, ok := ts.data[id]; ok {
+		if _, ok := ts.projects[task.ProjectID]; !ok {
+			return ErrProjectNotFound
+		}
+		if err := ts.checkParent(id, task.ParentID); err != nil {
+			return err
+		}
+		ts.unindex(current)
 		ts.data[id] = task
 		task.ID = id
+		ts.index(task)
 		return nil
 	}
 	return ErrTaskNotFound
@@ -95,18 +209,105 @@ func (ts *TasksStorage) Get(id uint64) (*entity.Task, error) {
 }
 
 func (ts *TasksStorage) GetAll() ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
 	if ts.length == 0 {
 		return nil, ErrStorageEmpty
 	}
 	data := make([]*entity.Task, 0, ts.length)
-	ts.mu.RLock()
 	for _, task := range ts.data {
 		data = append(data, task)
 	}
-	ts.mu.RUnlock()
 	return data, nil
 }
 
+func (ts *TasksStorage) FindByTags(tags []string, matchAll bool) ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	matches := make(map[uint64]int)
+	for _, tag := range tags {
+		for id := range ts.tags[tag] {
+			matches[id]++
+		}
+	}
+	data := make([]*entity.Task, 0, len(matches))
+	for id, count := range matches {
+		if !matchAll || count == len(tags) {
+			data = append(data, ts.data[id])
+		}
+	}
+	return data, nil
+}
+
+func (ts *TasksStorage) TagCounts() (map[string]int, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	counts := make(map[string]int, len(ts.tags))
+	for tag, ids := range ts.tags {
+		counts[tag] = len(ids)
+	}
+	return counts, nil
+}
+
+func (ts *TasksStorage) RenameTag(from, to string, updatedAt time.Time) (int, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return 0, ErrClosed
+	}
+	ids, ok := ts.tags[from]
+	if !ok {
+		return 0, ErrTagNotFound
+	}
+	if from == to {
+		return len(ids), nil
+	}
+	renamed := 0
+	for id := range ids {
+		current := ts.data[id]
+		task := *current
+		task.Tags = make([]string, 0, len(current.Tags))
+		for _, tag := range current.Tags {
+			if tag != from && tag != to {
+				task.Tags = append(task.Tags, tag)
+			}
+		}
+		task.Tags = append(task.Tags, to)
+		slices.Sort(task.Tags)
+		task.UpdatedAt = updatedAt
+		ts.unindexTags(current)
+		ts.data[id] = &task
+		ts.indexTags(&task)
+		renamed++
+	}
+	return renamed, nil
+}
+
+func (ts *TasksStorage) SetQuota(maxTasks uint64) {
+	ts.mu.Lock()
+	ts.maxTasks = maxTasks
+	ts.mu.Unlock()
+}
+
+func (ts *TasksStorage) Close() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	ts.closed = true
+	return nil
+}
+
+func (ts *TasksStorage) Ping(ctx context.Context) error {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
 func (ts *TasksStorage) PrintAll() {
 	for _, task := range ts.data {
 		task.PrintTask()
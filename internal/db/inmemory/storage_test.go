@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math"
 	"testing"
+	"time"
 	"webServerEx/internal/entity"
 )
 
@@ -126,6 +127,25 @@ func TestStorageGetAll(t *testing.T) {
 			t.Errorf("uncorrect length from get: %d", len(tasks))
 		}
 	})
+
+	t.Run("get all concurrently with add", func(t *testing.T) {
+		storage := NewStorage()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				storage.Add(&entity.Task{Title: "task"})
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			storage.GetAll()
+		}
+		<-done
+		tasks, _ := storage.GetAll()
+		if len(tasks) != 100 {
+			t.Errorf("uncorrect length from get: %d", len(tasks))
+		}
+	})
 }
 
 func TestStorageDelete(t *testing.T) {
@@ -266,3 +286,246 @@ func TestStorageUpdate(t *testing.T) {
 		}
 	})
 }
+
+func TestStorageClose(t *testing.T) {
+	t.Run("close storage", func(t *testing.T) {
+		storage := NewStorage()
+
+		if err := storage.Close(); err != nil {
+			t.Error(err.Error())
+		}
+		err := storage.Add(&entity.Task{Title: "test"})
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+
+	t.Run("writes after close", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Add(&entity.Task{Title: "test"})
+		storage.AddComment(&entity.Comment{TaskID: 0, Body: "test"})
+		storage.Close()
+
+		if err := storage.Delete(0); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+		if err := storage.DeleteAll(); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+		if err := storage.DeleteComment(0); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+
+	t.Run("close twice", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Close()
+
+		err := storage.Close()
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+}
+
+func TestStorageTags(t *testing.T) {
+	storage := NewStorage()
+	storage.Add(&entity.Task{Title: "first", Tags: []string{"home", "work"}})
+	storage.Add(&entity.Task{Title: "second", Tags: []string{"work"}})
+	storage.Add(&entity.Task{Title: "third", Tags: []string{"urgent"}})
+
+	t.Run("find by tags", func(t *testing.T) {
+		tasks, _ := storage.FindByTags([]string{"home", "work"}, true)
+		if len(tasks) != 1 || tasks[0].Title != "first" {
+			t.Errorf("uncorrect AND result: %v", tasks)
+		}
+		tasks, _ = storage.FindByTags([]string{"home", "urgent"}, false)
+		if len(tasks) != 2 {
+			t.Errorf("expected 2 tasks, got %d", len(tasks))
+		}
+	})
+
+	t.Run("index follows updates", func(t *testing.T) {
+		storage.Update(1, &entity.Task{Title: "second", Tags: []string{"home"}})
+		storage.Delete(2)
+		counts, _ := storage.TagCounts()
+		if counts["home"] != 2 || counts["work"] != 1 || counts["urgent"] != 0 {
+			t.Errorf("uncorrect counts: %v", counts)
+		}
+	})
+
+	t.Run("rename merges tags", func(t *testing.T) {
+		renamed, err := storage.RenameTag("work", "home", time.Now())
+		if err != nil || renamed != 1 {
+			t.Fatalf("uncorrect rename: %d %v", renamed, err)
+		}
+		task, _ := storage.Get(0)
+		if len(task.Tags) != 1 || task.Tags[0] != "home" {
+			t.Errorf("uncorrect merged tags: %v", task.Tags)
+		}
+		counts, _ := storage.TagCounts()
+		if len(counts) != 1 || counts["home"] != 2 {
+			t.Errorf("uncorrect counts after merge: %v", counts)
+		}
+		if _, err := storage.RenameTag("work", "job", time.Now()); !errors.Is(err, ErrTagNotFound) {
+			t.Errorf("expected ErrTagNotFound, got %v", err)
+		}
+	})
+}
+
+func TestStorageProjects(t *testing.T) {
+	t.Run("task in unknown project", func(t *testing.T) {
+		storage := NewStorage()
+
+		err := storage.Add(&entity.Task{Title: "test", ProjectID: 5})
+		if !errors.Is(err, ErrProjectNotFound) {
+			t.Errorf("expected ErrProjectNotFound, got %v", err)
+		}
+	})
+
+	t.Run("project index follows updates", func(t *testing.T) {
+		storage := NewStorage()
+		project := &entity.Project{Name: "work"}
+		storage.AddProject(project)
+		storage.Add(&entity.Task{Title: "test", ProjectID: project.ID})
+
+		storage.Update(0, &entity.Task{Title: "test"})
+		tasks, _ := storage.FindByProject(project.ID)
+		if len(tasks) != 0 {
+			t.Errorf("uncorrect project tasks: %d", len(tasks))
+		}
+		tasks, _ = storage.FindByProject(entity.InboxProjectID)
+		if len(tasks) != 1 {
+			t.Errorf("uncorrect inbox tasks: %d", len(tasks))
+		}
+	})
+
+	t.Run("delete inbox", func(t *testing.T) {
+		storage := NewStorage()
+
+		_, err := storage.DeleteProject(entity.InboxProjectID, entity.ProjectDeleteCascade, time.Now())
+		if !errors.Is(err, ErrInboxProject) {
+			t.Errorf("expected ErrInboxProject, got %v", err)
+		}
+	})
+}
+
+func TestStorageSubtasks(t *testing.T) {
+	t.Run("update guards against cycles", func(t *testing.T) {
+		storage := NewStorage()
+		root := uint64(0)
+		storage.Add(&entity.Task{Title: "parent"})
+		storage.Add(&entity.Task{Title: "child", ParentID: &root})
+
+		child := uint64(1)
+		err := storage.Update(0, &entity.Task{Title: "parent", ParentID: &child})
+		if !errors.Is(err, ErrTaskCycle) {
+			t.Errorf("expected ErrTaskCycle, got %v", err)
+		}
+	})
+
+	t.Run("delete removes subtree", func(t *testing.T) {
+		storage := NewStorage()
+		root, child := uint64(0), uint64(1)
+		storage.Add(&entity.Task{Title: "parent"})
+		storage.Add(&entity.Task{Title: "child", ParentID: &root})
+		storage.Add(&entity.Task{Title: "grandchild", ParentID: &child})
+
+		if err := storage.Delete(0); err != nil {
+			t.Fatal(err.Error())
+		}
+		if storage.length != 0 || len(storage.children) != 0 {
+			t.Errorf("uncorrect length after delete: %d", storage.length)
+		}
+	})
+}
+
+func TestStorageDependencies(t *testing.T) {
+	t.Run("transitive cycle", func(t *testing.T) {
+		storage := NewStorage()
+		for range 3 {
+			storage.Add(&entity.Task{Title: "test"})
+		}
+		storage.AddDependency(0, 1, time.Now())
+		storage.AddDependency(1, 2, time.Now())
+
+		err := storage.AddDependency(2, 0, time.Now())
+		if !errors.Is(err, ErrDependencyCycle) {
+			t.Errorf("expected ErrDependencyCycle, got %v", err)
+		}
+	})
+
+	t.Run("remove unknown dependency", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Add(&entity.Task{Title: "test"})
+
+		err := storage.RemoveDependency(0, 1, time.Now())
+		if !errors.Is(err, ErrDependencyNotFound) {
+			t.Errorf("expected ErrDependencyNotFound, got %v", err)
+		}
+	})
+}
+
+func TestStorageComments(t *testing.T) {
+	t.Run("comment on unknown task", func(t *testing.T) {
+		storage := NewStorage()
+
+		err := storage.AddComment(&entity.Comment{TaskID: 0, Body: "test"})
+		if !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("list comments page", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Add(&entity.Task{Title: "test"})
+		for _, body := range []string{"first", "second", "third"} {
+			storage.AddComment(&entity.Comment{TaskID: 0, Body: body})
+		}
+
+		comments, total, err := storage.ListComments(0, 1, 1)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if total != 3 || len(comments) != 1 || comments[0].Body != "second" {
+			t.Errorf("uncorrect comments page: %d %v", total, comments)
+		}
+		if comments, _, _ = storage.ListComments(0, 5, 1); len(comments) != 0 {
+			t.Errorf("expected empty page, got %v", comments)
+		}
+	})
+
+	t.Run("delete comment", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Add(&entity.Task{Title: "test"})
+		storage.AddComment(&entity.Comment{TaskID: 0, Body: "first"})
+		storage.AddComment(&entity.Comment{TaskID: 0, Body: "second"})
+
+		if err := storage.DeleteComment(0); err != nil {
+			t.Fatal(err.Error())
+		}
+		comments, total, _ := storage.ListComments(0, 0, 0)
+		if total != 1 || comments[0].Body != "second" {
+			t.Errorf("uncorrect comments: %v", comments)
+		}
+		if err := storage.DeleteComment(0); !errors.Is(err, ErrCommentNotFound) {
+			t.Errorf("expected ErrCommentNotFound, got %v", err)
+		}
+	})
+
+	t.Run("cascade on task delete", func(t *testing.T) {
+		storage := NewStorage()
+		storage.Add(&entity.Task{Title: "parent"})
+		parentID := uint64(0)
+		storage.Add(&entity.Task{Title: "child", ParentID: &parentID})
+		storage.AddComment(&entity.Comment{TaskID: 0, Body: "parent"})
+		storage.AddComment(&entity.Comment{TaskID: 1, Body: "child"})
+
+		storage.Delete(0)
+		for id := range uint64(2) {
+			if _, err := storage.GetComment(id); !errors.Is(err, ErrCommentNotFound) {
+				t.Errorf("expected ErrCommentNotFound, got %v", err)
+			}
+		}
+	})
+}
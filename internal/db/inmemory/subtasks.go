@@ -0,0 +1,203 @@
+package inmemory
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+var (
+	ErrParentNotFound = errors.New("parent task not found")
+	ErrTaskCycle      = errors.New("task cannot be moved under itself")
+)
+
+func (ts *TasksStorage) indexParent(task *entity.Task) {
+	if task.ParentID == nil {
+		return
+	}
+	ids, ok := ts.children[*task.ParentID]
+	if !ok {
+		ids = make(map[uint64]struct{})
+		ts.children[*task.ParentID] = ids
+	}
+	ids[task.ID] = struct{}{}
+}
+
+func (ts *TasksStorage) unindexParent(task *entity.Task) {
+	if task.ParentID == nil {
+		return
+	}
+	delete(ts.children[*task.ParentID], task.ID)
+	if len(ts.children[*task.ParentID]) == 0 {
+		delete(ts.children, *task.ParentID)
+	}
+}
+
+func (ts *TasksStorage) checkParent(id uint64, parentID *uint64) error {
+	if parentID == nil {
+		return nil
+	}
+	for current := *parentID; ; {
+		if current == id {
+			return ErrTaskCycle
+		}
+		parent, ok := ts.data[current]
+		if !ok {
+			return ErrParentNotFound
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		current = *parent.ParentID
+	}
+}
+
+func (ts *TasksStorage) descendants(id uint64) []*entity.Task {
+	var data []*entity.Task
+	queue := []uint64{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for childID := range ts.children[current] {
+			data = append(data, ts.data[childID])
+			queue = append(queue, childID)
+		}
+	}
+	return data
+}
+
+func (ts *TasksStorage) replace(current *entity.Task, update func(task *entity.Task)) {
+	task := *current
+	update(&task)
+	ts.unindex(current)
+	ts.data[task.ID] = &task
+	ts.index(&task)
+}
+
+func (ts *TasksStorage) Children(id uint64) ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.data[id]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	data := make([]*entity.Task, 0, len(ts.children[id]))
+	for childID := range ts.children[id] {
+		data = append(data, ts.data[childID])
+	}
+	return data, nil
+}
+
+func (ts *TasksStorage) Descendants(id uint64) ([]*entity.Task, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.data[id]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	return ts.descendants(id), nil
+}
+
+func (ts *TasksStorage) MoveSubtree(id uint64, parentID *uint64, projectID uint64, updatedAt time.Time) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	current, ok := ts.data[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if _, ok := ts.projects[projectID]; !ok {
+		return ErrProjectNotFound
+	}
+	if err := ts.checkParent(id, parentID); err != nil {
+		return err
+	}
+	for _, descendant := range ts.descendants(id) {
+		if descendant.ProjectID != projectID {
+			ts.replace(descendant, func(task *entity.Task) {
+				task.ProjectID = projectID
+				task.UpdatedAt = updatedAt
+			})
+		}
+	}
+	ts.replace(current, func(task *entity.Task) {
+		task.ParentID = parentID
+		task.ProjectID = projectID
+		task.UpdatedAt = updatedAt
+	})
+	return nil
+}
+
+func (ts *TasksStorage) UpdateSubtree(id uint64, task *entity.Task, finished, added []*entity.Task) error {
+	if task == nil || slices.Contains(finished, nil) || slices.Contains(added, nil) {
+		return ErrTaskIsNil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.closed {
+		return ErrClosed
+	}
+	current, ok := ts.data[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if _, ok := ts.projects[task.ProjectID]; !ok {
+		return ErrProjectNotFound
+	}
+	if err := ts.checkParent(id, task.ParentID); err != nil {
+		return err
+	}
+	descendants := make(map[uint64]*entity.Task)
+	for _, descendant := range ts.descendants(id) {
+		descendants[descendant.ID] = descendant
+	}
+	for _, update := range finished {
+		if descendants[update.ID] == nil {
+			return ErrTaskNotFound
+		}
+	}
+	count := uint64(len(added))
+	if ts.length > math.MaxUint64-count || ts.currentId > math.MaxUint64-count {
+		return ErrTooManyTasks
+	}
+	if ts.maxTasks > 0 && ts.length+count > ts.maxTasks {
+		return ErrQuota
+	}
+	for _, next := range added {
+		if _, ok := ts.projects[next.ProjectID]; !ok {
+			return ErrProjectNotFound
+		}
+		if next.ParentID != nil && ts.data[*next.ParentID] == nil {
+			return ErrParentNotFound
+		}
+	}
+	for _, update := range finished {
+		ts.replace(descendants[update.ID], func(task *entity.Task) {
+			*task = *update
+		})
+	}
+	if task.ProjectID != current.ProjectID {
+		for _, descendant := range ts.descendants(id) {
+			if descendant.ProjectID != task.ProjectID {
+				ts.replace(descendant, func(descendant *entity.Task) {
+					descendant.ProjectID = task.ProjectID
+					descendant.UpdatedAt = task.UpdatedAt
+				})
+			}
+		}
+	}
+	ts.unindex(current)
+	ts.data[id] = task
+	task.ID = id
+	ts.index(task)
+	for _, next := range added {
+		next.ID = ts.currentId
+		ts.data[next.ID] = next
+		ts.index(next)
+		ts.currentId++
+		ts.length++
+	}
+	return nil
+}
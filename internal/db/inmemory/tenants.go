@@ -0,0 +1,90 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrEmptyTenant    = errors.New("tenant id is empty")
+	ErrTenantNotFound = errors.New("tenant not found")
+)
+
+type TenantStorage struct {
+	quota   func(tenantID string) uint64
+	tenants map[string]*TasksStorage
+	closed  bool
+	mu      sync.Mutex
+}
+
+func NewTenantStorage(quota func(tenantID string) uint64) *TenantStorage {
+	return &TenantStorage{
+		quota:   quota,
+		tenants: make(map[string]*TasksStorage),
+	}
+}
+
+func (s *TenantStorage) Provision(tenantID string) error {
+	if tenantID == "" {
+		return ErrEmptyTenant
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.tenants[tenantID]; ok {
+		return nil
+	}
+	storage := NewStorage()
+	if s.quota != nil {
+		storage.SetQuota(s.quota(tenantID))
+	}
+	s.tenants[tenantID] = storage
+	return nil
+}
+
+func (s *TenantStorage) Has(tenantID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.tenants[tenantID]
+	return ok
+}
+
+func (s *TenantStorage) ForTenant(tenantID string) (*TasksStorage, error) {
+	if tenantID == "" {
+		return nil, ErrEmptyTenant
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if storage, ok := s.tenants[tenantID]; ok {
+		return storage, nil
+	}
+	return nil, ErrTenantNotFound
+}
+
+func (s *TenantStorage) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+func (s *TenantStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	for _, storage := range s.tenants {
+		storage.Close()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"webServerEx/internal/entity"
+)
+
+func TestTenantStorage(t *testing.T) {
+	t.Run("tenants have separate sequences", func(t *testing.T) {
+		storage := NewTenantStorage(nil)
+		storage.Provision("first")
+		storage.Provision("second")
+		first, _ := storage.ForTenant("first")
+		second, _ := storage.ForTenant("second")
+		firstTask := &entity.Task{Title: "first"}
+		secondTask := &entity.Task{Title: "second"}
+
+		first.Add(&entity.Task{Title: "zero"})
+		first.Add(firstTask)
+		second.Add(secondTask)
+		if firstTask.ID != 1 || secondTask.ID != 0 {
+			t.Errorf("uncorrect ids: %d %d", firstTask.ID, secondTask.ID)
+		}
+		if _, err := second.Get(1); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+		again, _ := storage.ForTenant("first")
+		if again != first {
+			t.Error("expected the same partition for the same tenant")
+		}
+	})
+
+	t.Run("tenant quota", func(t *testing.T) {
+		storage := NewTenantStorage(func(tenantID string) uint64 {
+			if tenantID == "small" {
+				return 1
+			}
+			return 0
+		})
+		storage.Provision("small")
+		storage.Provision("big")
+		small, _ := storage.ForTenant("small")
+		big, _ := storage.ForTenant("big")
+
+		if err := small.Add(&entity.Task{Title: "one"}); err != nil {
+			t.Error(err.Error())
+		}
+		if err := small.Add(&entity.Task{Title: "two"}); !errors.Is(err, ErrQuota) {
+			t.Errorf("expected ErrQuota, got %v", err)
+		}
+		for range 3 {
+			if err := big.Add(&entity.Task{Title: "task"}); err != nil {
+				t.Error(err.Error())
+			}
+		}
+	})
+
+	t.Run("empty tenant and close", func(t *testing.T) {
+		storage := NewTenantStorage(nil)
+		if _, err := storage.ForTenant(""); !errors.Is(err, ErrEmptyTenant) {
+			t.Errorf("expected ErrEmptyTenant, got %v", err)
+		}
+		storage.Provision("first")
+		first, _ := storage.ForTenant("first")
+
+		storage.Close()
+		if err := storage.Ping(context.Background()); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+		if err := first.Add(&entity.Task{Title: "late"}); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+		if err := storage.Provision("second"); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		storage := NewTenantStorage(nil)
+		storage.Provision("first")
+
+		if _, err := storage.ForTenant("second"); !errors.Is(err, ErrTenantNotFound) {
+			t.Errorf("expected ErrTenantNotFound, got %v", err)
+		}
+		if storage.Has("second") || !storage.Has("first") {
+			t.Error("only provisioned tenants must exist")
+		}
+	})
+}
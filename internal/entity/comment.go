@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Comment struct {
+	ID        uint64    `json:"id"`
+	TaskID    uint64    `json:"task_id"`
+	Body      string    `json:"body"`
+	AuthorID  string    `json:"author_id,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
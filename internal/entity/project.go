@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+const InboxProjectID uint64 = 0
+
+const (
+	ProjectDeleteCascade  = "cascade"
+	ProjectDeleteInbox    = "inbox"
+	ProjectDeleteRestrict = "restrict"
+)
+
+type Project struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	Archived  bool      `json:"archived"`
+	Position  int       `json:"position"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewInbox() *Project {
+	return &Project{ID: InboxProjectID, Name: "Inbox"}
+}
@@ -1,12 +1,46 @@
 package entity
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Task struct {
-	ID          uint64 `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Finished    bool   `json:"finished"`
+	ID          uint64      `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Finished    bool        `json:"finished"`
+	Status      string      `json:"status,omitempty"`
+	ProjectID   uint64      `json:"project_id"`
+	ParentID    *uint64     `json:"parent_id,omitempty"`
+	Progress    *Progress   `json:"progress,omitempty"`
+	BlockedBy   []uint64    `json:"blocked_by,omitempty"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+	SeriesID    *uint64     `json:"series_id,omitempty"`
+	OwnerID     string      `json:"owner_id,omitempty"`
+	CreatedBy   string      `json:"created_by,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+	DueAt       *time.Time  `json:"due_at,omitempty"`
+	DueAllDay   bool        `json:"due_all_day,omitempty"`
+	Priority    string      `json:"priority,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+}
+
+type Recurrence struct {
+	Frequency string     `json:"freq"`
+	Interval  int        `json:"interval,omitempty"`
+	Weekdays  []string   `json:"weekdays,omitempty"`
+	MonthDay  int        `json:"month_day,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Count     int        `json:"count,omitempty"`
+	Timezone  string     `json:"timezone,omitempty"`
+}
+
+type Progress struct {
+	Finished int `json:"finished"`
+	Total    int `json:"total"`
 }
 
 func NewTask(id uint64, title string, description string) *Task {
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/tenant"
+)
+
+type AccountsHandler struct {
+	users        auth.UserStore
+	sessions     auth.SessionStore
+	secureCookie bool
+	registration bool
+}
+
+func NewAccountsHandler(users auth.UserStore, sessions auth.SessionStore, secureCookie, registration bool) *AccountsHandler {
+	return &AccountsHandler{
+		users:        users,
+		sessions:     sessions,
+		secureCookie: secureCookie,
+		registration: registration,
+	}
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type sessionResponse struct {
+	User      *auth.User `json:"user"`
+	CSRFToken string     `json:"csrf_token"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+func (h *AccountsHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if !h.registration {
+		http.Error(w, "registration is disabled", http.StatusForbidden)
+		return
+	}
+	var request credentials
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+	if request.Timezone != "" {
+		if _, err := time.LoadLocation(request.Timezone); err != nil {
+			http.Error(w, auth.ErrInvalidTimezone.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	tenantID, _ := tenant.FromContext(r.Context())
+	user, err := h.users.Register(request.Username, request.Password, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidUsername), errors.Is(err, auth.ErrWeakPassword):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, auth.ErrUserExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if request.Timezone != "" {
+		if err := h.users.SetTimezone(user.ID, request.Timezone); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user, _ = h.users.Get(user.ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AccountsHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request credentials
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+	user, err := h.users.Authenticate(request.Username, request.Password)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		h.sessions.Delete(cookie.Value)
+	}
+	token, session, err := h.sessions.Create(user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	h.writeSession(w, user, session)
+}
+
+func (h *AccountsHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		h.sessions.Delete(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AccountsHandler) Session(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(auth.SessionCookieName)
+	if err != nil {
+		http.Error(w, auth.ErrSessionNotFound.Error(), http.StatusUnauthorized)
+		return
+	}
+	session, err := h.sessions.Get(cookie.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, err := h.users.Get(session.UserID)
+	if err != nil {
+		http.Error(w, auth.ErrSessionNotFound.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.writeSession(w, user, session)
+}
+
+func (h *AccountsHandler) writeSession(w http.ResponseWriter, user *auth.User, session *auth.Session) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	response := sessionResponse{User: user, CSRFToken: session.CSRFToken, ExpiresAt: session.ExpiresAt}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+)
+
+func newTestAccountsHandler(t *testing.T, registration bool) *AccountsHandler {
+	t.Helper()
+	users, err := auth.NewUserStore(1000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return NewAccountsHandler(users, auth.NewSessionStore(time.Hour), true, registration)
+}
+
+func TestAccountsHandlerRegister(t *testing.T) {
+	t.Run("handlerRegister correct user", func(t *testing.T) {
+		handler := newTestAccountsHandler(t, true)
+		body := `{"username":"alice","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.Register(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "password") {
+			t.Error("response must not contain password data")
+		}
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+		handler.Register(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerRegister disabled", func(t *testing.T) {
+		handler := newTestAccountsHandler(t, false)
+		body := `{"username":"alice","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.Register(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+		}
+	})
+}
+
+func TestAccountsHandlerLogin(t *testing.T) {
+	t.Run("handlerLogin sets session cookie", func(t *testing.T) {
+		handler := newTestAccountsHandler(t, true)
+		handler.users.Register("alice", "password123", "")
+		body := `{"username":"alice","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.Login(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		cookies := rec.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != auth.SessionCookieName {
+			t.Fatalf("uncorrect cookies: %v", cookies)
+		}
+		if !cookies[0].HttpOnly || !cookies[0].Secure {
+			t.Error("session cookie must be HttpOnly and Secure")
+		}
+		var response struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to read JSON %v", err)
+		}
+		if response.CSRFToken == "" {
+			t.Error("expected csrf token")
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/auth/session", nil)
+		req.AddCookie(cookies[0])
+		rec = httptest.NewRecorder()
+		handler.Session(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+		req.AddCookie(cookies[0])
+		rec = httptest.NewRecorder()
+		handler.Logout(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		if _, err := handler.sessions.Get(cookies[0].Value); err == nil {
+			t.Error("session must be deleted after logout")
+		}
+	})
+
+	t.Run("handlerLogin wrong password", func(t *testing.T) {
+		handler := newTestAccountsHandler(t, true)
+		handler.users.Register("alice", "password123", "")
+		body := `{"username":"alice","password":"password"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.Login(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status http.StatusUnauthorized, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	dependencies, err := h.service.GetDependencies(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(dependencies); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *Handler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		BlockedBy *uint64 `json:"blocked_by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.BlockedBy == nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+	err := h.service.AddDependency(r.Context(), r.PathValue("id"), strconv.FormatUint(*request.BlockedBy, 10))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RemoveDependency(r.Context(), r.PathValue("id"), r.PathValue("blocker"))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) PlanTasks(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTaskFilter(r.URL.Query(), loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, err := h.service.PlanTasks(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tasks); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/service"
+)
+
+const TimezoneHeader = "X-Timezone"
+
+var (
+	ErrInvalidFilter   = errors.New("invalid filter")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+func requestLocation(r *http.Request) (*time.Location, error) {
+	name := r.Header.Get(TimezoneHeader)
+	if name == "" {
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			name = principal.Timezone
+		}
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+func parseDue(value string) (*time.Time, bool, error) {
+	if value == "" {
+		return nil, false, nil
+	}
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		return &day, true, nil
+	}
+	due, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: due_at must be a date or an RFC 3339 time", service.ErrInvalidDue)
+	}
+	return &due, false, nil
+}
+
+func parseDueBound(query url.Values, name string, loc *time.Location) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return day, nil
+	}
+	return parseTime(query, name)
+}
+
+func parseTime(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time", ErrInvalidFilter, name)
+	}
+	return parsed, nil
+}
+
+func parseTaskFilter(query url.Values, loc *time.Location) (service.TaskFilter, error) {
+	filter := service.TaskFilter{Location: loc}
+	var err error
+	if filter.CreatedSince, err = parseTime(query, "created_since"); err != nil {
+		return filter, err
+	}
+	if filter.UpdatedSince, err = parseTime(query, "updated_since"); err != nil {
+		return filter, err
+	}
+	if filter.CompletedSince, err = parseTime(query, "completed_since"); err != nil {
+		return filter, err
+	}
+	if filter.DueBefore, err = parseDueBound(query, "due_before", loc); err != nil {
+		return filter, err
+	}
+	if filter.DueAfter, err = parseDueBound(query, "due_after", loc); err != nil {
+		return filter, err
+	}
+	if filter.Due = query.Get("due"); filter.Due != "" && !service.ValidDue(filter.Due) {
+		return filter, fmt.Errorf("%w: due must be today, tomorrow or week", ErrInvalidFilter)
+	}
+	if value := query.Get("overdue"); value != "" {
+		if filter.Overdue, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("%w: overdue must be a boolean", ErrInvalidFilter)
+		}
+	}
+	for _, value := range query["priority"] {
+		for _, item := range strings.Split(value, ",") {
+			priority, err := service.NormalizePriority(item)
+			if err != nil {
+				return filter, fmt.Errorf("%w: priority must be P0-P3 or none", ErrInvalidFilter)
+			}
+			filter.Priorities = append(filter.Priorities, priority)
+		}
+	}
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			filter.Statuses = append(filter.Statuses, strings.TrimSpace(status))
+		}
+	}
+	for _, tag := range query["tag"] {
+		tag, err := service.NormalizeTag(tag)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+		filter.Tags = append(filter.Tags, tag)
+	}
+	if value := query.Get("project"); value != "" {
+		project, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("%w: project must be a project id", ErrInvalidFilter)
+		}
+		filter.Project = &project
+	}
+	if value := query.Get("blocked"); value != "" {
+		blocked, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("%w: blocked must be a boolean", ErrInvalidFilter)
+		}
+		filter.Blocked = &blocked
+	}
+	if value := query.Get("series"); value != "" {
+		series, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("%w: series must be a task id", ErrInvalidFilter)
+		}
+		filter.Series = &series
+	}
+	switch query.Get("tag_mode") {
+	case "", "all":
+	case "any":
+		filter.AnyTag = true
+	default:
+		return filter, fmt.Errorf("%w: tag_mode must be all or any", ErrInvalidFilter)
+	}
+	if filter.Sort = query.Get("sort"); filter.Sort != "" && !service.ValidSort(filter.Sort) {
+		return filter, fmt.Errorf("%w: sort must be id, priority, due, created or updated", ErrInvalidFilter)
+	}
+	return filter, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/service"
+)
+
+func TestParseDue(t *testing.T) {
+	dueAt, allDay, err := parseDue("2024-03-07")
+	if err != nil || !allDay || !dueAt.Equal(time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("uncorrect all-day due: %v %v %v", dueAt, allDay, err)
+	}
+	dueAt, allDay, err = parseDue("2024-03-07T18:00:00+03:00")
+	if err != nil || allDay || !dueAt.Equal(time.Date(2024, time.March, 7, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("uncorrect timed due: %v %v %v", dueAt, allDay, err)
+	}
+	if _, _, err = parseDue("next friday"); !errors.Is(err, service.ErrInvalidDue) {
+		t.Errorf("expected ErrInvalidDue, got %v", err)
+	}
+}
+
+func TestParseTaskFilter(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	filter, err := parseTaskFilter(url.Values{"due_before": {"2024-03-07"}, "overdue": {"true"}, "due": {"today"}}, loc)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !filter.DueBefore.Equal(time.Date(2024, time.March, 7, 0, 0, 0, 0, loc)) || !filter.Overdue || filter.Due != service.DueToday {
+		t.Errorf("uncorrect filter: %+v", filter)
+	}
+	for _, query := range []url.Values{{"due": {"someday"}}, {"overdue": {"maybe"}}} {
+		if _, err := parseTaskFilter(query, loc); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%v: expected ErrInvalidFilter, got %v", query, err)
+		}
+	}
+}
+
+func TestRequestLocation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/todos/agenda", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Timezone: "Europe/Moscow"}))
+	loc, err := requestLocation(req)
+	if err != nil || loc.String() != "Europe/Moscow" {
+		t.Errorf("expected timezone from principal, got %v %v", loc, err)
+	}
+
+	req.Header.Set(TimezoneHeader, "Asia/Tokyo")
+	loc, err = requestLocation(req)
+	if err != nil || loc.String() != "Asia/Tokyo" {
+		t.Errorf("expected timezone from header, got %v %v", loc, err)
+	}
+
+	req.Header.Set(TimezoneHeader, "Mars/Olympus")
+	handler := NewHandler(mockService{})
+	rec := httptest.NewRecorder()
+	handler.GetAgenda(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+	}
+}
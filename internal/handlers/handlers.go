@@ -3,7 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"webServerEx/internal/db/inmemory"
 	"webServerEx/internal/entity"
 	"webServerEx/internal/service"
@@ -17,19 +21,102 @@ func NewHandler(service service.Service) *Handler {
 	return &Handler{service: service}
 }
 
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, inmemory.ErrTaskNotFound), errors.Is(err, service.ErrTaskNotFound), errors.Is(err, service.ErrNoTasks),
+		errors.Is(err, inmemory.ErrTagNotFound), errors.Is(err, inmemory.ErrProjectNotFound), errors.Is(err, service.ErrProjectNotFound),
+		errors.Is(err, inmemory.ErrParentNotFound), errors.Is(err, service.ErrParentNotFound), errors.Is(err, inmemory.ErrDependencyNotFound),
+		errors.Is(err, inmemory.ErrCommentNotFound), errors.Is(err, service.ErrCommentNotFound), errors.Is(err, inmemory.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, inmemory.ErrProjectNotEmpty), errors.Is(err, service.ErrProjectArchived), errors.Is(err, inmemory.ErrTaskCycle),
+		errors.Is(err, service.ErrTaskBlocked), errors.Is(err, inmemory.ErrDependencyCycle),
+		errors.Is(err, service.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, inmemory.ErrQuota):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidTitle), errors.Is(err, service.ErrInvalidID), errors.Is(err, service.ErrInvalidDue),
+		errors.Is(err, service.ErrInvalidPriority), errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrInvalidProject),
+		errors.Is(err, service.ErrInboxProject), errors.Is(err, inmemory.ErrInboxProject), errors.Is(err, service.ErrInvalidDeletePolicy),
+		errors.Is(err, service.ErrInvalidParent), errors.Is(err, service.ErrInvalidRecurrence),
+		errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrInvalidComment), errors.Is(err, service.ErrInvalidPage):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+type taskRequest struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Finished    bool            `json:"finished"`
+	Status      string          `json:"status"`
+	DueAt       string          `json:"due_at"`
+	Priority    string          `json:"priority"`
+	Tags        []string        `json:"tags"`
+	ProjectID   *uint64         `json:"project_id"`
+	ParentID    *uint64         `json:"parent_id"`
+	Recurrence  recurrenceField `json:"recurrence"`
+}
+
+type recurrenceField struct {
+	Set  bool
+	Rule *entity.Recurrence
+}
+
+func (f *recurrenceField) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	return json.Unmarshal(data, &f.Rule)
+}
+
+const StatusCountsHeader = "X-Status-Counts"
+
+func formatStatusCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, status := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, status+"="+strconv.Itoa(counts[status]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (tr taskRequest) input(r *http.Request) (service.TaskInput, error) {
+	dueAt, allDay, err := parseDue(tr.DueAt)
+	if err != nil {
+		return service.TaskInput{}, err
+	}
+	recurrence := tr.Recurrence.Rule
+	if recurrence != nil && recurrence.Timezone == "" {
+		loc, err := requestLocation(r)
+		if err != nil {
+			return service.TaskInput{}, err
+		}
+		rule := *recurrence
+		rule.Timezone = loc.String()
+		recurrence = &rule
+	}
+	return service.TaskInput{
+		Title:           tr.Title,
+		Description:     tr.Description,
+		Finished:        tr.Finished,
+		Status:          tr.Status,
+		DueAt:           dueAt,
+		DueAllDay:       allDay,
+		Priority:        tr.Priority,
+		Tags:            tr.Tags,
+		ProjectID:       tr.ProjectID,
+		ParentID:        tr.ParentID,
+		Recurrence:      recurrence,
+		ClearRecurrence: tr.Recurrence.Set && recurrence == nil,
+	}, nil
+}
+
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
 		http.Error(w, "invalid input id", http.StatusBadRequest)
 		return
 	}
-	task, err := h.service.GetTask(id)
+	task, err := h.service.GetTask(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, inmemory.ErrTaskNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -41,12 +128,27 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	var tasks []*entity.Task
-	tasks, err := h.service.GetAllTasks()
+	loc, err := requestLocation(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	filter, err := parseTaskFilter(r.URL.Query(), loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, err := h.service.GetAllTasks(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	counts, err := h.service.StatusCounts(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.Header().Set(StatusCountsHeader, formatStatusCounts(counts))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(tasks); err != nil {
@@ -56,21 +158,20 @@ func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-	}
+	var request taskRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "invalid input", http.StatusBadRequest)
 		return
 	}
-	err := h.service.AddTask(request.Title, request.Description)
+	input, err := request.input(r)
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidTitle) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = h.service.AddTask(r.Context(), input)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
@@ -81,22 +182,25 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid input id", http.StatusBadRequest)
 		return
 	}
-	var request struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Finished    bool   `json:"finished"`
-	}
+	var request taskRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "invalid input", http.StatusBadRequest)
 		return
 	}
-	err := h.service.UpdateTask(id, request.Title, request.Description, request.Finished)
+	input, err := request.input(r)
 	if err != nil {
-		if errors.Is(err, inmemory.ErrTaskNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if value := r.URL.Query().Get("force"); value != "" {
+		if input.Force, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "invalid input: force must be a boolean", http.StatusBadRequest)
+			return
 		}
+	}
+	err = h.service.UpdateTask(r.Context(), id, input)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -108,23 +212,57 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid input id", http.StatusBadRequest)
 		return
 	}
-	err := h.service.DeleteTask(id)
+	err := h.service.DeleteTask(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, inmemory.ErrTaskNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
 func (h *Handler) DeleteTasks(w http.ResponseWriter, r *http.Request) {
-	err := h.service.DeleteAllTasks()
+	err := h.service.DeleteAllTasks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) GetAgenda(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	agenda, err := h.service.GetAgenda(r.Context(), loc)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(agenda); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *Handler) NextTask(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	task, err := h.service.NextTask(r.Context(), loc)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 	"webServerEx/internal/db/inmemory"
 	"webServerEx/internal/entity"
 	"webServerEx/internal/service"
@@ -15,15 +17,15 @@ type mockService struct {
 	err error
 }
 
-func (m mockService) AddTask(title, description string) error {
+func (m mockService) AddTask(ctx context.Context, input service.TaskInput) error {
 	return m.err
 }
 
-func (m mockService) UpdateTask(id, title, description string, finished bool) error {
+func (m mockService) UpdateTask(ctx context.Context, id string, input service.TaskInput) error {
 	return m.err
 }
 
-func (m mockService) GetTask(id string) (*entity.Task, error) {
+func (m mockService) GetTask(ctx context.Context, id string) (*entity.Task, error) {
 	switch id {
 	case "1":
 		return &entity.Task{ID: 1, Title: "test"}, nil
@@ -35,18 +37,118 @@ func (m mockService) GetTask(id string) (*entity.Task, error) {
 	return nil, nil
 }
 
-func (m mockService) GetAllTasks() ([]*entity.Task, error) {
+func (m mockService) GetAllTasks(ctx context.Context, filter service.TaskFilter) ([]*entity.Task, error) {
 	return nil, m.err
 }
 
-func (m mockService) DeleteTask(id string) error {
+func (m mockService) DeleteTask(ctx context.Context, id string) error {
 	return m.err
 }
 
-func (m mockService) DeleteAllTasks() error {
+func (m mockService) DeleteAllTasks(ctx context.Context) error {
 	return m.err
 }
 
+func (m mockService) NextTask(ctx context.Context, loc *time.Location) (*entity.Task, error) {
+	return &entity.Task{ID: 1, Title: "next"}, m.err
+}
+
+func (m mockService) ListTags(ctx context.Context) ([]service.TagCount, error) {
+	return []service.TagCount{{Tag: "work", Count: 2}}, m.err
+}
+
+func (m mockService) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return 2, m.err
+}
+
+func (m mockService) ListProjects(ctx context.Context, archived bool) ([]*entity.Project, error) {
+	return []*entity.Project{entity.NewInbox()}, m.err
+}
+
+func (m mockService) GetProject(ctx context.Context, id string) (*entity.Project, error) {
+	return entity.NewInbox(), m.err
+}
+
+func (m mockService) AddProject(ctx context.Context, input service.ProjectInput) (*entity.Project, error) {
+	return &entity.Project{ID: 1, Name: input.Name}, m.err
+}
+
+func (m mockService) UpdateProject(ctx context.Context, id string, input service.ProjectInput) error {
+	return m.err
+}
+
+func (m mockService) DeleteProject(ctx context.Context, id string, policy string) (int, error) {
+	return 3, m.err
+}
+
+func (m mockService) GetChildren(ctx context.Context, id string) ([]*entity.Task, error) {
+	return []*entity.Task{{ID: 1, Title: "child"}}, m.err
+}
+
+func (m mockService) GetSubtree(ctx context.Context, id string) (*service.TaskTree, error) {
+	return &service.TaskTree{Task: &entity.Task{ID: 0, Title: "parent"}, Children: []*service.TaskTree{{Task: &entity.Task{ID: 1, Title: "child"}}}}, m.err
+}
+
+func (m mockService) MoveTask(ctx context.Context, id string, parentID *uint64) error {
+	return m.err
+}
+
+func (m mockService) GetDependencies(ctx context.Context, id string) (*service.Dependencies, error) {
+	return &service.Dependencies{BlockedBy: []*entity.Task{{ID: 1}}, Blocks: []*entity.Task{}}, m.err
+}
+
+func (m mockService) AddDependency(ctx context.Context, id, blockerID string) error {
+	return m.err
+}
+
+func (m mockService) RemoveDependency(ctx context.Context, id, blockerID string) error {
+	return m.err
+}
+
+func (m mockService) PlanTasks(ctx context.Context, filter service.TaskFilter) ([]*entity.Task, error) {
+	return []*entity.Task{{ID: 1}, {ID: 0}}, m.err
+}
+
+func (m mockService) TransitionTask(ctx context.Context, id string, status string, force bool) (*entity.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &entity.Task{ID: 1, Status: status}, nil
+}
+
+func (m mockService) StatusCounts(ctx context.Context, filter service.TaskFilter) (map[string]int, error) {
+	return map[string]int{service.StatusTodo: 2, service.StatusDone: 1}, m.err
+}
+
+func (m mockService) ListComments(ctx context.Context, taskID string, offset, limit int) (*service.CommentsPage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.CommentsPage{Comments: []*entity.Comment{{ID: 0, Body: "first"}}, Total: 1, Offset: offset, Limit: limit}, nil
+}
+
+func (m mockService) AddComment(ctx context.Context, taskID, body string) (*entity.Comment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &entity.Comment{ID: 0, Body: body}, nil
+}
+
+func (m mockService) UpdateComment(ctx context.Context, taskID, id, body string) (*entity.Comment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &entity.Comment{ID: 0, Body: body}, nil
+}
+
+func (m mockService) DeleteComment(ctx context.Context, taskID, id string) error {
+	return m.err
+}
+
+func (m mockService) GetAgenda(ctx context.Context, loc *time.Location) (*service.Agenda, error) {
+	return &service.Agenda{}, m.err
+}
+
 func TestHandlerGetTask(t *testing.T) {
 	t.Run("handlerGet correct task", func(t *testing.T) {
 		mock := mockService{}
@@ -119,6 +221,18 @@ func TestHandlerGetAllTasks(t *testing.T) {
 		}
 	})
 
+	t.Run("handlerGet all tasks invalid filter", func(t *testing.T) {
+		mock := mockService{}
+		handler := NewHandler(mock)
+		req := httptest.NewRequest(http.MethodGet, "/todos?updated_since=yesterday", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetAllTasks(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
 	t.Run("handlerGet all tasks fail 400", func(t *testing.T) {
 		mock := mockService{err: inmemory.ErrStorageEmpty}
 		handler := NewHandler(mock)
@@ -286,3 +400,380 @@ func TestHandlerUpdateTask(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerNextTask(t *testing.T) {
+	t.Run("handlerNext task", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodGet, "/todos/next", nil)
+		rec := httptest.NewRecorder()
+
+		handler.NextTask(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerNext task no tasks 404", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrNoTasks})
+		req := httptest.NewRequest(http.MethodGet, "/todos/next", nil)
+		rec := httptest.NewRecorder()
+
+		handler.NextTask(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status http.StatusNotFound, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandlerTags(t *testing.T) {
+	t.Run("handlerList tags", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		rec := httptest.NewRecorder()
+
+		handler.ListTags(rec, httptest.NewRequest(http.MethodGet, "/tags", nil))
+		var tags []service.TagCount
+		if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil || len(tags) != 1 {
+			t.Errorf("uncorrect tags: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("handlerRename unknown tag 404", func(t *testing.T) {
+		handler := NewHandler(mockService{err: inmemory.ErrTagNotFound})
+		req := httptest.NewRequest(http.MethodPut, "/tags/work", strings.NewReader(`{"name":"job"}`))
+		req.SetPathValue("tag", "work")
+		rec := httptest.NewRecorder()
+
+		handler.RenameTag(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status http.StatusNotFound, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandlerProjects(t *testing.T) {
+	t.Run("handlerCreate project", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		rec := httptest.NewRecorder()
+
+		handler.CreateProject(rec, httptest.NewRequest(http.MethodPost, "/projects", strings.NewReader(`{"name":"Work"}`)))
+		if rec.Code != http.StatusCreated {
+			t.Errorf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+		var project entity.Project
+		if err := json.Unmarshal(rec.Body.Bytes(), &project); err != nil || project.ID != 1 || project.Name != "Work" {
+			t.Errorf("uncorrect project: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("handlerDelete non-empty project 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: inmemory.ErrProjectNotEmpty})
+		req := httptest.NewRequest(http.MethodDelete, "/projects/1", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handler.DeleteProject(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerProject tasks invalid id", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodGet, "/projects/abc/todos", nil)
+		req.SetPathValue("id", "abc")
+		rec := httptest.NewRecorder()
+
+		handler.GetProjectTasks(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerCreate task in archived project 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrProjectArchived})
+		req := httptest.NewRequest(http.MethodPost, "/projects/1/todos", strings.NewReader(`{"title":"test"}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handler.CreateProjectTask(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandlerSubtasks(t *testing.T) {
+	t.Run("handlerGet subtree", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodGet, "/todos/0/subtree", nil)
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.GetSubtree(rec, req)
+		var tree struct {
+			Title    string `json:"title"`
+			Children []struct {
+				Title string `json:"title"`
+			} `json:"children"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil || tree.Title != "parent" || len(tree.Children) != 1 {
+			t.Errorf("uncorrect subtree: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("handlerMove task cycle 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: inmemory.ErrTaskCycle})
+		req := httptest.NewRequest(http.MethodPut, "/todos/0/parent", strings.NewReader(`{"parent_id":1}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.MoveTask(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandlerDependencies(t *testing.T) {
+	t.Run("handlerAdd dependency cycle 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: inmemory.ErrDependencyCycle})
+		req := httptest.NewRequest(http.MethodPost, "/todos/0/dependencies", strings.NewReader(`{"blocked_by":1}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.AddDependency(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerAdd dependency without blocker", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodPost, "/todos/0/dependencies", strings.NewReader(`{}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.AddDependency(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerFinish blocked task 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrTaskBlocked})
+		req := httptest.NewRequest(http.MethodPut, "/todos/0", strings.NewReader(`{"title":"test","finished":true}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.UpdateTask(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerPlan invalid blocked filter", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		rec := httptest.NewRecorder()
+
+		handler.PlanTasks(rec, httptest.NewRequest(http.MethodGet, "/todos/order?blocked=maybe", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandlerRecurrence(t *testing.T) {
+	t.Run("handlerCreate recurring task with invalid timezone", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"test","due_at":"2024-03-07","recurrence":{"freq":"daily"}}`))
+		req.Header.Set(TimezoneHeader, "Mars/Base")
+		rec := httptest.NewRecorder()
+
+		handler.CreateTask(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerCreate task with invalid recurrence", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrInvalidRecurrence})
+		req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"test","recurrence":{"freq":"hourly"}}`))
+		rec := httptest.NewRecorder()
+
+		handler.CreateTask(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	tableTests := []struct {
+		name  string
+		body  string
+		rule  bool
+		clear bool
+	}{
+		{name: "recurrence omitted", body: `{"title":"test"}`},
+		{name: "recurrence null", body: `{"title":"test","recurrence":null}`, clear: true},
+		{name: "recurrence set", body: `{"title":"test","recurrence":{"freq":"daily"}}`, rule: true},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request taskRequest
+			if err := json.Unmarshal([]byte(tt.body), &request); err != nil {
+				t.Fatal(err.Error())
+			}
+			input, err := request.input(httptest.NewRequest(http.MethodPut, "/todos/1", nil))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if (input.Recurrence != nil) != tt.rule || input.ClearRecurrence != tt.clear {
+				t.Errorf("uncorrect recurrence input: %+v %v", input.Recurrence, input.ClearRecurrence)
+			}
+		})
+	}
+}
+
+func TestHandlerWorkflow(t *testing.T) {
+	t.Run("handlerTransition task", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodPost, "/todos/1/transitions", strings.NewReader(`{"status":"review"}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handler.TransitionTask(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		var task entity.Task
+		if err := json.NewDecoder(rec.Body).Decode(&task); err != nil {
+			t.Fatal(err.Error())
+		}
+		if task.Status != "review" {
+			t.Errorf("uncorrect status: %s", task.Status)
+		}
+	})
+
+	t.Run("handlerTransition not allowed 409", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrInvalidTransition})
+		req := httptest.NewRequest(http.MethodPost, "/todos/1/transitions", strings.NewReader(`{"status":"review"}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handler.TransitionTask(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected status http.StatusConflict, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerTransition invalid status 400", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrInvalidStatus})
+		req := httptest.NewRequest(http.MethodPost, "/todos/1/transitions", strings.NewReader(`{"status":"archived"}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handler.TransitionTask(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerGetAll with status counts", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		rec := httptest.NewRecorder()
+
+		handler.GetAllTasks(rec, httptest.NewRequest(http.MethodGet, "/todos?status=todo,done", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		if counts := rec.Header().Get(StatusCountsHeader); counts != "done=1, todo=2" {
+			t.Errorf("uncorrect status counts: %q", counts)
+		}
+		var tasks []*entity.Task
+		if err := json.NewDecoder(rec.Body).Decode(&tasks); err != nil {
+			t.Fatalf("expected task list body, got %v", err)
+		}
+	})
+}
+
+func TestHandlerComments(t *testing.T) {
+	t.Run("handlerAdd comment", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodPost, "/todos/0/comments", strings.NewReader(`{"body":"looks good"}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.AddComment(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerAdd empty comment", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrInvalidComment})
+		req := httptest.NewRequest(http.MethodPost, "/todos/0/comments", strings.NewReader(`{"body":""}`))
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.AddComment(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerList comments page", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodGet, "/todos/0/comments?offset=0&limit=10", nil)
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.ListComments(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		var page service.CommentsPage
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatal(err.Error())
+		}
+		if page.Total != 1 || page.Limit != 10 || len(page.Comments) != 1 {
+			t.Errorf("uncorrect page: %+v", page)
+		}
+	})
+
+	t.Run("handlerList invalid limit", func(t *testing.T) {
+		handler := NewHandler(mockService{})
+		req := httptest.NewRequest(http.MethodGet, "/todos/0/comments?limit=many", nil)
+		req.SetPathValue("id", "0")
+		rec := httptest.NewRecorder()
+
+		handler.ListComments(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerUpdate foreign comment 403", func(t *testing.T) {
+		handler := NewHandler(mockService{err: service.ErrForbidden})
+		req := httptest.NewRequest(http.MethodPut, "/todos/0/comments/1", strings.NewReader(`{"body":"edited"}`))
+		req.SetPathValue("id", "0")
+		req.SetPathValue("comment", "1")
+		rec := httptest.NewRecorder()
+
+		handler.UpdateComment(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerDelete missing comment 404", func(t *testing.T) {
+		handler := NewHandler(mockService{err: inmemory.ErrCommentNotFound})
+		req := httptest.NewRequest(http.MethodDelete, "/todos/0/comments/1", nil)
+		req.SetPathValue("id", "0")
+		req.SetPathValue("comment", "1")
+		rec := httptest.NewRecorder()
+
+		handler.DeleteComment(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status http.StatusNotFound, got %d", rec.Code)
+		}
+	})
+}
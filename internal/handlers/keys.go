@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/tenant"
+)
+
+type KeysHandler struct {
+	keys auth.KeyStore
+}
+
+func NewKeysHandler(keys auth.KeyStore) *KeysHandler {
+	return &KeysHandler{keys: keys}
+}
+
+func (h *KeysHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name     string   `json:"name"`
+		Scopes   []string `json:"scopes"`
+		TenantID string   `json:"tenant_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid input", http.StatusBadRequest)
+		return
+	}
+	if request.TenantID != "" && !tenant.Valid(request.TenantID) {
+		http.Error(w, tenant.ErrInvalidTenant.Error(), http.StatusBadRequest)
+		return
+	}
+	callerTenant := principalTenant(r)
+	if callerTenant != "" {
+		if request.TenantID != "" && request.TenantID != callerTenant {
+			http.Error(w, tenant.ErrTenantMismatch.Error(), http.StatusForbidden)
+			return
+		}
+		request.TenantID = callerTenant
+	}
+	key, apiKey, err := h.keys.Mint(request.Name, request.TenantID, request.Scopes)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidName) || errors.Is(err, auth.ErrInvalidScope) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	response := struct {
+		*auth.APIKey
+		Key string `json:"key"`
+	}{APIKey: apiKey, Key: key}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func principalTenant(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.TenantID
+	}
+	return ""
+}
+
+func (h *KeysHandler) visibleKeys(r *http.Request) []*auth.APIKey {
+	keys := h.keys.List()
+	callerTenant := principalTenant(r)
+	if callerTenant == "" {
+		return keys
+	}
+	visible := keys[:0]
+	for _, key := range keys {
+		if key.TenantID == callerTenant {
+			visible = append(visible, key)
+		}
+	}
+	return visible
+}
+
+func (h *KeysHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys := h.visibleKeys(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *KeysHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "invalid input id", http.StatusBadRequest)
+		return
+	}
+	visible := false
+	for _, key := range h.visibleKeys(r) {
+		if key.ID == id {
+			visible = true
+			break
+		}
+	}
+	if !visible {
+		http.Error(w, auth.ErrKeyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if err := h.keys.Revoke(id); err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
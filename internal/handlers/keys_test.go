@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webServerEx/internal/auth"
+)
+
+func TestKeysHandlerCreateKey(t *testing.T) {
+	t.Run("handlerCreateKey correct key", func(t *testing.T) {
+		handler := NewKeysHandler(auth.NewKeyStore())
+		body := `{"name":"ci","scopes":["tasks:read"]}`
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.CreateKey(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+		var response struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to read JSON %v", err)
+		}
+		if response.ID == "" || !strings.HasPrefix(response.Key, "tsk_") {
+			t.Errorf("uncorrect response: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("handlerCreateKey invalid scope", func(t *testing.T) {
+		handler := NewKeysHandler(auth.NewKeyStore())
+		body := `{"name":"ci","scopes":["root"]}`
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler.CreateKey(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status http.StatusBadRequest, got %d", rec.Code)
+		}
+	})
+}
+
+func TestKeysHandlerRevokeKey(t *testing.T) {
+	t.Run("handlerRevokeKey correct key", func(t *testing.T) {
+		keys := auth.NewKeyStore()
+		_, apiKey, _ := keys.Mint("ci", "", []string{auth.ScopeTasksRead})
+		handler := NewKeysHandler(keys)
+		req := httptest.NewRequest(http.MethodDelete, "/admin/keys/"+apiKey.ID, nil)
+		req.SetPathValue("id", apiKey.ID)
+		rec := httptest.NewRecorder()
+
+		handler.RevokeKey(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handlerRevokeKey fail 404", func(t *testing.T) {
+		handler := NewKeysHandler(auth.NewKeyStore())
+		req := httptest.NewRequest(http.MethodDelete, "/admin/keys/missing", nil)
+		req.SetPathValue("id", "missing")
+		rec := httptest.NewRecorder()
+
+		handler.RevokeKey(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status http.StatusNotFound, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/service"
+)
+
+type PermissionsHandler struct {
+	authorizer *service.Authorizer
+}
+
+func NewPermissionsHandler(authorizer *service.Authorizer) *PermissionsHandler {
+	return &PermissionsHandler{authorizer: authorizer}
+}
+
+func (h *PermissionsHandler) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	response := struct {
+		Principal   *auth.Principal `json:"principal"`
+		Roles       []string        `json:"roles"`
+		Permissions map[string]bool `json:"permissions"`
+	}{
+		Principal:   principal,
+		Roles:       []string{},
+		Permissions: h.authorizer.Permissions(principal),
+	}
+	if principal != nil {
+		response.Roles = principal.EffectiveRoles()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/service"
+)
+
+func TestPermissionsHandler(t *testing.T) {
+	t.Run("handlerPermissions viewer", func(t *testing.T) {
+		authorizer, _ := service.NewAuthorizer(nil)
+		handler := NewPermissionsHandler(authorizer)
+		req := httptest.NewRequest(http.MethodGet, "/me/permissions", nil)
+		principal := &auth.Principal{ID: "1", Kind: auth.KindAPIKey, Scopes: []string{auth.ScopeTasksRead}}
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+
+		handler.GetPermissions(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		var response struct {
+			Roles       []string        `json:"roles"`
+			Permissions map[string]bool `json:"permissions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to read JSON %v", err)
+		}
+		if len(response.Roles) != 1 || response.Roles[0] != auth.RoleViewer {
+			t.Errorf("uncorrect roles: %v", response.Roles)
+		}
+		if !response.Permissions[service.ActionTasksRead] || response.Permissions[service.ActionTasksCreate] {
+			t.Errorf("uncorrect permissions: %v", response.Permissions)
+		}
+	})
+}
+
+func TestHandlerForbidden(t *testing.T) {
+	t.Run("handlerGet all tasks fail 403", func(t *testing.T) {
+		mock := mockService{err: service.ErrForbidden}
+		handler := NewHandler(mock)
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetAllTasks(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistryRun(t *testing.T) {
+	t.Run("run passing checks", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("first", 0, func(ctx context.Context) error { return nil })
+		registry.Register("second", time.Second, func(ctx context.Context) error { return nil })
+
+		report := registry.Run(context.Background())
+		if !report.Healthy() {
+			t.Errorf("expected healthy report, got %v", report)
+		}
+		if len(report.Checks) != 2 {
+			t.Errorf("uncorrect checks count: %d", len(report.Checks))
+		}
+	})
+
+	t.Run("run failing check", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("ok", 0, func(ctx context.Context) error { return nil })
+		registry.Register("broken", 0, func(ctx context.Context) error { return errors.New("broken") })
+
+		report := registry.Run(context.Background())
+		if report.Healthy() {
+			t.Error("expected unhealthy report")
+		}
+		if report.Checks[1].Error != "broken" {
+			t.Errorf("uncorrect check error: %s", report.Checks[1].Error)
+		}
+	})
+
+	t.Run("run check with timeout", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("slow", 10*time.Millisecond, func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+
+		report := registry.Run(context.Background())
+		if report.Checks[0].Error != ErrCheckTimeout.Error() {
+			t.Errorf("expected ErrCheckTimeout, got %s", report.Checks[0].Error)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("handler healthy", func(t *testing.T) {
+		registry := NewRegistry()
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		rec := httptest.NewRecorder()
+
+		Handler(registry)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+
+	t.Run("handler verbose unhealthy", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("storage", 0, func(ctx context.Context) error { return errors.New("closed") })
+		req := httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil)
+		rec := httptest.NewRecorder()
+
+		Handler(registry)(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status http.StatusServiceUnavailable, got %d", rec.Code)
+		}
+		var report Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("failed to read JSON %v", err)
+		}
+		if len(report.Checks) != 1 || report.Checks[0].Name != "storage" {
+			t.Errorf("uncorrect report: %v", report)
+		}
+	})
+}
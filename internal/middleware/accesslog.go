@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatCommon   = "common"
+	FormatCombined = "combined"
+	FormatJSON     = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown access log format")
+
+type AccessEntry struct {
+	Time      time.Time `json:"time"`
+	RemoteIP  string    `json:"remote_ip"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	LatencyMS float64   `json:"latency_ms"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (e AccessEntry) common() string {
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.FormatInt(e.Bytes, 10)
+	}
+	return fmt.Sprintf("%s - - [%s] %q %d %s",
+		orDash(e.RemoteIP), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method+" "+e.URI+" "+e.Proto, e.Status, size)
+}
+
+func FormatEntry(format string, e AccessEntry) ([]byte, error) {
+	switch format {
+	case FormatCommon:
+		return []byte(e.common() + "\n"), nil
+	case FormatCombined:
+		return []byte(fmt.Sprintf("%s %q %q\n", e.common(), orDash(e.Referer), orDash(e.UserAgent))), nil
+	case FormatJSON:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+func AccessLogMiddleware(out io.Writer, format string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lrw := NewLogResponseWriter(w)
+			next.ServeHTTP(lrw, r)
+
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			line, err := FormatEntry(format, AccessEntry{
+				Time:      start,
+				RemoteIP:  host,
+				Method:    r.Method,
+				URI:       r.RequestURI,
+				Proto:     r.Proto,
+				Status:    lrw.statusCode,
+				Bytes:     lrw.bytes,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Referer:   r.Referer(),
+				UserAgent: r.UserAgent(),
+				RequestID: RequestIDFromContext(r.Context()),
+			})
+			if err == nil {
+				_, err = out.Write(line)
+			}
+			if err != nil {
+				log.Printf("Failed to write access log: %v", err)
+			}
+		})
+	}
+}
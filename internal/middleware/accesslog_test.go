@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatEntry(t *testing.T) {
+	entry := AccessEntry{
+		Time:      time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC),
+		RemoteIP:  "203.0.113.7",
+		Method:    http.MethodGet,
+		URI:       "/todos?limit=1",
+		Proto:     "HTTP/1.1",
+		Status:    http.StatusOK,
+		Bytes:     42,
+		UserAgent: "curl/8.0",
+	}
+	tableTests := []struct {
+		format   string
+		expected string
+	}{
+		{format: FormatCommon, expected: `203.0.113.7 - - [05/Mar/2024:10:00:00 +0000] "GET /todos?limit=1 HTTP/1.1" 200 42` + "\n"},
+		{format: FormatCombined, expected: `203.0.113.7 - - [05/Mar/2024:10:00:00 +0000] "GET /todos?limit=1 HTTP/1.1" 200 42 "-" "curl/8.0"` + "\n"},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.format, func(t *testing.T) {
+			line, err := FormatEntry(tt.format, entry)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if string(line) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, line)
+			}
+		})
+	}
+	if _, err := FormatEntry("xml", entry); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	handler := AccessLogMiddleware(&out, FormatJSON)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+		http.NewResponseController(w).Flush()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader("{}"))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+	if !rec.Flushed {
+		t.Error("flush was not passed through")
+	}
+	var entry AccessEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal(err.Error())
+	}
+	if entry.Status != http.StatusCreated || entry.Bytes != 5 || entry.Method != http.MethodPost {
+		t.Errorf("uncorrect entry: %+v", entry)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type ClientIdentity struct {
+	Subject    string   `json:"subject"`
+	CommonName string   `json:"common_name"`
+	DNSNames   []string `json:"dns_names,omitempty"`
+	Emails     []string `json:"emails,omitempty"`
+	URIs       []string `json:"uris,omitempty"`
+}
+
+type clientIdentityKey struct{}
+
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey{}).(*ClientIdentity)
+	return identity, ok
+}
+
+func ClientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cert := r.TLS.PeerCertificates[0]
+		identity := &ClientIdentity{
+			Subject:    cert.Subject.String(),
+			CommonName: cert.Subject.CommonName,
+			DNSNames:   cert.DNSNames,
+			Emails:     cert.EmailAddresses,
+		}
+		for _, uri := range cert.URIs {
+			identity.URIs = append(identity.URIs, uri.String())
+		}
+		ctx := context.WithValue(r.Context(), clientIdentityKey{}, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
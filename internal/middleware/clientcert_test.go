@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientCertMiddleware(t *testing.T) {
+	t.Run("identity from verified certificate", func(t *testing.T) {
+		var identity *ClientIdentity
+		handler := ClientCertMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, _ = ClientIdentityFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		cert := &x509.Certificate{
+			Subject:  pkix.Name{CommonName: "client"},
+			DNSNames: []string{"client.local"},
+		}
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if identity == nil {
+			t.Fatal("identity not found in context")
+		}
+		if identity.CommonName != "client" || identity.DNSNames[0] != "client.local" {
+			t.Errorf("uncorrect identity: %v", identity)
+		}
+	})
+
+	t.Run("no identity without TLS", func(t *testing.T) {
+		found := false
+		handler := ClientCertMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, found = ClientIdentityFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if found {
+			t.Error("unexpected identity in context")
+		}
+	})
+	t.Run("no identity for unverified certificate", func(t *testing.T) {
+		found := false
+		handler := ClientCertMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, found = ClientIdentityFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if found {
+			t.Error("unexpected identity in context")
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tableTests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "gzip", expected: "gzip"},
+		{header: "deflate, gzip", expected: "gzip"},
+		{header: "gzip;q=0.5, deflate", expected: "deflate"},
+		{header: "gzip;q=0, deflate;q=0", expected: ""},
+		{header: "br, *;q=0.1", expected: "gzip"},
+		{header: "*;q=0", expected: ""},
+		{header: "identity", expected: ""},
+	}
+	for _, tt := range tableTests {
+		if encoding := NegotiateEncoding(tt.header); encoding != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.header, tt.expected, encoding)
+		}
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"title":"task"},`, 200)
+
+	t.Run("large response is compressed", func(t *testing.T) {
+		handler := CompressMiddleware(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, large)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("expected gzip encoding, got %q", rec.Header().Get("Content-Encoding"))
+		}
+		if rec.Header().Get("ETag") != `W/"v1"` || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("uncorrect headers: %v", rec.Header())
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		body, _ := io.ReadAll(zr)
+		if string(body) != large {
+			t.Error("uncorrect decompressed body")
+		}
+	})
+
+	t.Run("small response is not compressed", func(t *testing.T) {
+		handler := CompressMiddleware(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id":1}`)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/todos", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"id":1}` {
+			t.Errorf("uncorrect response: %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+		}
+	})
+
+	t.Run("flush streams compressed data", func(t *testing.T) {
+		handler := CompressMiddleware(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "data: first\n\n")
+			http.NewResponseController(w).Flush()
+			io.WriteString(w, "data: second\n\n")
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.Header.Set("Accept-Encoding", "deflate")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if !rec.Flushed || rec.Header().Get("Content-Encoding") != "deflate" {
+			t.Fatalf("uncorrect streaming response: %v", rec.Header())
+		}
+		body, _ := io.ReadAll(flate.NewReader(rec.Body))
+		if string(body) != "data: first\n\ndata: second\n\n" {
+			t.Errorf("uncorrect body: %q", body)
+		}
+	})
+
+	t.Run("already encoded response is untouched", func(t *testing.T) {
+		handler := CompressMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, large)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Header().Get("Content-Encoding") != "br" || rec.Body.String() != large {
+			t.Error("pre-encoded response must not be compressed again")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	patterns := []string{"https://app.example.com", "https://*.example.org"}
+	tableTests := []struct {
+		origin   string
+		expected bool
+	}{
+		{origin: "https://app.example.com", expected: true},
+		{origin: "https://evil.com", expected: false},
+		{origin: "https://team.example.org", expected: true},
+		{origin: "https://example.org", expected: false},
+		{origin: "https://evil.com/.example.org", expected: false},
+		{origin: "http://team.example.org", expected: false},
+	}
+	for _, tt := range tableTests {
+		if allowed := OriginAllowed(patterns, tt.origin); allowed != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.origin, tt.expected, allowed)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	reached := false
+	handler := CORSMiddleware(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	t.Run("preflight", func(t *testing.T) {
+		reached = false
+		req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type, authorization")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent || reached {
+			t.Errorf("preflight must be answered by middleware, got %d", rec.Code)
+		}
+		h := rec.Header()
+		if h.Get("Access-Control-Allow-Origin") != "https://app.example.com" || h.Get("Access-Control-Allow-Credentials") != "true" ||
+			h.Get("Access-Control-Allow-Methods") != "GET, POST" || h.Get("Access-Control-Max-Age") != "60" {
+			t.Errorf("uncorrect preflight headers: %v", h)
+		}
+	})
+
+	t.Run("preflight with disallowed method", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Header().Get("Access-Control-Allow-Methods") != "" {
+			t.Errorf("unexpected allowed methods: %v", rec.Header())
+		}
+	})
+
+	t.Run("simple request", func(t *testing.T) {
+		reached = false
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if !reached || rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+			t.Errorf("uncorrect simple response: %v", rec.Header())
+		}
+	})
+
+	t.Run("unknown origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Origin", "https://evil.com")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" || rec.Header().Get("Vary") != "Origin" {
+			t.Errorf("uncorrect headers for unknown origin: %v", rec.Header())
+		}
+	})
+}
+
+func TestOptionsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todos", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /todos", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("DELETE /todos/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := OptionsMiddleware(mux)
+
+	tableTests := []struct {
+		path   string
+		status int
+		allow  string
+	}{
+		{path: "/todos", status: http.StatusNoContent, allow: "GET, HEAD, POST, OPTIONS"},
+		{path: "/todos/1", status: http.StatusNoContent, allow: "DELETE, OPTIONS"},
+		{path: "/missing", status: http.StatusNotFound},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, tt.path, nil))
+			if rec.Code != tt.status || rec.Header().Get("Allow") != tt.allow {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.allow, rec.Code, rec.Header().Get("Allow"))
+			}
+		})
+	}
+}
@@ -1,29 +1,75 @@
 package middleware
 
 import (
+	"bufio"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"time"
 )
 
 type logResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	bytes       int64
+	wroteHeader bool
 }
 
 func NewLogResponseWriter(w http.ResponseWriter) *logResponseWriter {
-	return &logResponseWriter{w, http.StatusOK}
+	return &logResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (lrw *logResponseWriter) WriteHeader(code int) {
-	lrw.statusCode = code
+	if !lrw.wroteHeader {
+		lrw.statusCode = code
+		lrw.wroteHeader = code >= 200 || code == http.StatusSwitchingProtocols
+	}
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+func (lrw *logResponseWriter) Write(b []byte) (int, error) {
+	lrw.wroteHeader = true
+	n, err := lrw.ResponseWriter.Write(b)
+	lrw.bytes += int64(n)
+	return n, err
+}
+
+func (lrw *logResponseWriter) Flush() {
+	lrw.wroteHeader = true
+	http.NewResponseController(lrw.ResponseWriter).Flush()
+}
+
+func (lrw *logResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := lrw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", lrw.ResponseWriter)
+	}
+	if !lrw.wroteHeader {
+		lrw.statusCode = http.StatusSwitchingProtocols
+		lrw.wroteHeader = true
+	}
+	return hijacker.Hijack()
+}
+
+func (lrw *logResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+func (lrw *logResponseWriter) StatusCode() int {
+	return lrw.statusCode
+}
+
+func (lrw *logResponseWriter) BytesWritten() int64 {
+	return lrw.bytes
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Started %s %s", r.Method, r.URL.Path)
+		start := time.Now()
 		lrw := NewLogResponseWriter(w)
 		next.ServeHTTP(lrw, r)
-		log.Printf("Finished %s %s with StatusCode: %d", r.Method, r.URL.Path, lrw.statusCode)
+		log.Printf("Finished %s %s with StatusCode: %d (%d bytes in %s)", r.Method, r.URL.Path, lrw.statusCode, lrw.bytes, time.Since(start))
 	})
 }
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"webServerEx/internal/auth"
+)
+
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (l RateLimit) perSecond() float64 {
+	return float64(l.Limit) / l.Window.Seconds()
+}
+
+type RateLimiterOptions struct {
+	Read           RateLimit
+	Write          RateLimit
+	IdleTimeout    time.Duration
+	TrustedProxies []netip.Prefix
+	Now            func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	opts    RateLimiterOptions
+}
+
+func NewRateLimiter(opts RateLimiterOptions) *RateLimiter {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &RateLimiter{buckets: make(map[string]*bucket), opts: opts}
+}
+
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func (rl *RateLimiter) trusted(addr netip.Addr) bool {
+	for _, prefix := range rl.opts.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rl *RateLimiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+	if !rl.trusted(addr) {
+		return addr.String()
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !rl.trusted(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func (rl *RateLimiter) clientKey(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Subject()
+	}
+	return "ip:" + rl.ClientIP(r)
+}
+
+func (rl *RateLimiter) take(key string, limit RateLimit) (remaining int, reset, retryAfter time.Duration, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.opts.Now()
+	rate := limit.perSecond()
+	capacity := float64(limit.Limit)
+	b, found := rl.buckets[key]
+	if !found {
+		b = &bucket{tokens: capacity, updated: now}
+		rl.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		ok = true
+	} else {
+		retryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	return int(b.tokens), reset, retryAfter, ok
+}
+
+func (rl *RateLimiter) Evict() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := rl.opts.Now()
+	evicted := 0
+	for key, b := range rl.buckets {
+		if now.Sub(b.updated) >= rl.opts.IdleTimeout {
+			delete(rl.buckets, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+func (rl *RateLimiter) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rl.Evict()
+		}
+	}
+}
+
+func readMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, kind := rl.opts.Write, "write"
+		if readMethod(r.Method) {
+			limit, kind = rl.opts.Read, "read"
+		}
+		remaining, reset, retryAfter, ok := rl.take(rl.clientKey(r)+"|"+kind, limit)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", seconds(reset))
+		if !ok {
+			w.Header().Set("Retry-After", seconds(retryAfter))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
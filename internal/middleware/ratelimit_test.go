@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+)
+
+func newTestLimiter(t *testing.T, now *time.Time, proxies ...string) *RateLimiter {
+	t.Helper()
+	trusted, err := ParseTrustedProxies(proxies)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return NewRateLimiter(RateLimiterOptions{
+		Read:           RateLimit{Limit: 2, Window: time.Minute},
+		Write:          RateLimit{Limit: 1, Window: time.Minute},
+		IdleTimeout:    10 * time.Minute,
+		TrustedProxies: trusted,
+		Now:            func() time.Time { return *now },
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("limits and refills", func(t *testing.T) {
+		now := time.Now()
+		handler := newTestLimiter(t, &now).Middleware(ok)
+		for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+			if rec.Code != expected {
+				t.Errorf("request %d: expected status %d, got %d", i, expected, rec.Code)
+			}
+			if i == 2 && rec.Header().Get("Retry-After") != "30" {
+				t.Errorf("uncorrect Retry-After: %s", rec.Header().Get("Retry-After"))
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/todos", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("writes must have their own bucket, got %d", rec.Code)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" {
+			t.Errorf("uncorrect headers: %v", rec.Header())
+		}
+
+		now = now.Add(30 * time.Second)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK after refill, got %d", rec.Code)
+		}
+	})
+
+	t.Run("principals have separate buckets", func(t *testing.T) {
+		now := time.Now()
+		handler := newTestLimiter(t, &now).Middleware(ok)
+		for _, id := range []string{"alice", "bob"} {
+			req := httptest.NewRequest(http.MethodPost, "/todos", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{ID: id, Kind: auth.KindSession}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: expected status http.StatusOK, got %d", id, rec.Code)
+			}
+		}
+	})
+
+	t.Run("idle buckets are evicted", func(t *testing.T) {
+		now := time.Now()
+		limiter := newTestLimiter(t, &now)
+		limiter.Middleware(ok).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos", nil))
+		if evicted := limiter.Evict(); evicted != 0 {
+			t.Errorf("active bucket evicted: %d", evicted)
+		}
+		now = now.Add(10 * time.Minute)
+		if evicted := limiter.Evict(); evicted != 1 {
+			t.Errorf("expected 1 evicted bucket, got %d", evicted)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	tableTests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		expected  string
+	}{
+		{name: "direct client", remote: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{name: "untrusted proxy is ignored", remote: "203.0.113.7:5000", forwarded: "198.51.100.1", expected: "203.0.113.7"},
+		{name: "trusted proxy", remote: "10.0.0.2:5000", forwarded: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "spoofed hop before client", remote: "10.0.0.2:5000", forwarded: "1.2.3.4, 198.51.100.1, 10.0.0.3", expected: "198.51.100.1"},
+		{name: "garbage header", remote: "10.0.0.2:5000", forwarded: "unknown", expected: "10.0.0.2"},
+	}
+	now := time.Now()
+	limiter := newTestLimiter(t, &now, "10.0.0.0/8")
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if ip := limiter.ClientIP(req); ip != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var PanicsTotal = expvar.NewInt("http_panics_total")
+
+type PanicReport struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Value     string    `json:"value"`
+	Stack     string    `json:"stack"`
+}
+
+type PanicReporter interface {
+	Report(report PanicReport) error
+}
+
+type FileReporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileReporter(path string) (*FileReporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReporter{file: file}, nil
+}
+
+func (fr *FileReporter) Report(report PanicReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	_, err = fr.file.Write(append(data, '\n'))
+	return err
+}
+
+func (fr *FileReporter) Close() error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.file.Close()
+}
+
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rrw *recoveryResponseWriter) WriteHeader(code int) {
+	rrw.wroteHeader = true
+	rrw.ResponseWriter.WriteHeader(code)
+}
+
+func (rrw *recoveryResponseWriter) Write(b []byte) (int, error) {
+	rrw.wroteHeader = true
+	return rrw.ResponseWriter.Write(b)
+}
+
+func (rrw *recoveryResponseWriter) Unwrap() http.ResponseWriter {
+	return rrw.ResponseWriter
+}
+
+func RecoveryMiddleware(reporter PanicReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rrw := &recoveryResponseWriter{ResponseWriter: w}
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(value)
+				}
+				report := PanicReport{
+					Time:      time.Now().UTC(),
+					RequestID: RequestIDFromContext(r.Context()),
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					Value:     fmt.Sprint(value),
+					Stack:     string(debug.Stack()),
+				}
+				PanicsTotal.Add(1)
+				log.Printf("Panic in %s %s (request %s): %s\n%s", report.Method, report.Path, report.RequestID, report.Value, report.Stack)
+				if reporter != nil {
+					if err := reporter.Report(report); err != nil {
+						log.Printf("Failed to report panic: %v", err)
+					}
+				}
+				if rrw.wroteHeader {
+					return
+				}
+				w.Header().Del("Content-Length")
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(problem{
+					Type:      "about:blank",
+					Title:     http.StatusText(http.StatusInternalServerError),
+					Status:    http.StatusInternalServerError,
+					Instance:  r.URL.Path,
+					RequestID: report.RequestID,
+				})
+			}()
+			next.ServeHTTP(rrw, r)
+		})
+	}
+}
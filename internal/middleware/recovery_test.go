@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type memoryReporter struct {
+	reports []PanicReport
+}
+
+func (mr *memoryReporter) Report(report PanicReport) error {
+	mr.reports = append(mr.reports, report)
+	return nil
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Run("panic becomes problem response", func(t *testing.T) {
+		reporter := &memoryReporter{}
+		handler := RequestIDMiddleware(RecoveryMiddleware(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var task *struct{ Title string }
+			_ = task.Title
+		})))
+		before := PanicsTotal.Value()
+		req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status http.StatusInternalServerError, got %d", rec.Code)
+		}
+		if rec.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("uncorrect content type: %s", rec.Header().Get("Content-Type"))
+		}
+		var body problem
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if body.Status != http.StatusInternalServerError || body.RequestID != "req-1" {
+			t.Errorf("uncorrect problem: %+v", body)
+		}
+		if PanicsTotal.Value() != before+1 {
+			t.Error("panic metric was not incremented")
+		}
+		if len(reporter.reports) != 1 || reporter.reports[0].RequestID != "req-1" || !strings.Contains(reporter.reports[0].Stack, "recovery_test.go") {
+			t.Errorf("uncorrect reports: %+v", reporter.reports)
+		}
+	})
+
+	t.Run("abort handler is not recovered", func(t *testing.T) {
+		handler := RecoveryMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+		defer func() {
+			if recover() != http.ErrAbortHandler {
+				t.Error("expected http.ErrAbortHandler to propagate")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("file reporter", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "panics.log")
+		reporter, err := NewFileReporter(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		reporter.Report(PanicReport{Path: "/first", Value: "boom"})
+		reporter.Report(PanicReport{Path: "/second", Value: "boom"})
+		reporter.Close()
+
+		data, _ := os.ReadFile(path)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 reports, got %d", len(lines))
+		}
+		var report PanicReport
+		if err := json.Unmarshal([]byte(lines[1]), &report); err != nil || report.Path != "/second" {
+			t.Errorf("uncorrect report: %s", lines[1])
+		}
+	})
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var id string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if id == "" || rec.Header().Get(RequestIDHeader) != id {
+		t.Errorf("uncorrect generated id: %q", id)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if id == "bad id\n" {
+		t.Error("invalid request id must be replaced")
+	}
+}
@@ -1,39 +1,416 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/certs"
+	"webServerEx/internal/config"
 	"webServerEx/internal/db/inmemory"
 	"webServerEx/internal/handlers"
+	"webServerEx/internal/health"
 	"webServerEx/internal/middleware"
+	"webServerEx/internal/rotate"
 	"webServerEx/internal/service"
+	"webServerEx/internal/tenant"
 )
 
+var errDraining = errors.New("server is draining")
+
 type App struct {
-	handler *handlers.Handler
+	cfg          *config.Config
+	handler      *handlers.Handler
+	keysHandler  *handlers.KeysHandler
+	accounts     *handlers.AccountsHandler
+	permissions  *handlers.PermissionsHandler
+	authorizer   *service.Authorizer
+	keys         auth.KeyStore
+	authn        []auth.Authenticator
+	tenants      *inmemory.TenantStorage
+	limiter      *middleware.RateLimiter
+	reporter     middleware.PanicReporter
+	accessLog    io.Writer
+	server       *http.Server
+	hooks        shutdownHooks
+	liveness     *health.Registry
+	readiness    *health.Registry
+	draining     atomic.Bool
+	streamsCtx   context.Context
+	closeStreams context.CancelFunc
 }
 
-func NewApp() *App {
-	storage := inmemory.NewStorage()
-	repository := service.NewRepository(storage)
-	serviceTasks := service.NewTasksService(repository)
+func NewApp(cfg *config.Config) (*App, error) {
+	storage := inmemory.NewTenantStorage(cfg.Tenancy.Quota)
+	for _, tenantID := range cfg.Tenancy.KnownTenants() {
+		if !tenant.Valid(tenantID) {
+			return nil, fmt.Errorf("%w: %q", tenant.ErrInvalidTenant, tenantID)
+		}
+		if err := storage.Provision(tenantID); err != nil {
+			return nil, err
+		}
+	}
+	repositories := service.TenantRepositoriesFunc(func(tenantID string) (service.Repository, error) {
+		tasks, err := storage.ForTenant(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		return service.NewRepository(tasks), nil
+	})
+	authorizer, err := service.NewAuthorizer(cfg.Authz.Policies)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := service.NewWorkflow(service.WorkflowOptions{
+		Statuses:    cfg.Workflow.Statuses,
+		Initial:     cfg.Workflow.Initial,
+		Done:        cfg.Workflow.Done,
+		Terminal:    cfg.Workflow.Terminal,
+		Transitions: cfg.Workflow.Transitions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	serviceTasks := service.NewTasksService(repositories, authorizer, service.WithWorkflow(workflow))
 	handler := handlers.NewHandler(serviceTasks)
-	return &App{handler: handler}
+	keys := auth.NewKeyStore()
+	if cfg.Auth.AdminKeySHA256 != "" {
+		if _, err := keys.Import("bootstrap-admin", "", cfg.Auth.AdminKeySHA256, []string{auth.ScopeTasksAdmin}); err != nil {
+			return nil, err
+		}
+	}
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
+	a := &App{
+		cfg:          cfg,
+		handler:      handler,
+		keysHandler:  handlers.NewKeysHandler(keys),
+		keys:         keys,
+		tenants:      storage,
+		permissions:  handlers.NewPermissionsHandler(authorizer),
+		authorizer:   authorizer,
+		streamsCtx:   streamsCtx,
+		closeStreams: closeStreams,
+		liveness:     health.NewRegistry(),
+		readiness:    health.NewRegistry(),
+	}
+	a.readiness.Register("shutdown", 0, func(ctx context.Context) error {
+		if a.draining.Load() {
+			return errDraining
+		}
+		return nil
+	})
+	a.readiness.Register("storage", 0, storage.Ping)
+	if err := a.setupAuth(); err != nil {
+		return nil, err
+	}
+	if err := a.setupRateLimit(); err != nil {
+		return nil, err
+	}
+	if logCfg := cfg.AccessLog; logCfg.File != "" {
+		accessLog, err := rotate.Open(logCfg.File, rotate.Options{
+			MaxSize:  int64(logCfg.MaxSizeMB) << 20,
+			MaxAge:   logCfg.MaxAge.Duration,
+			Compress: logCfg.Compress,
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.accessLog = accessLog
+		a.AddShutdownHook("access log", func(ctx context.Context) error {
+			return accessLog.Close()
+		})
+	}
+	if path := cfg.Features.PanicReportFile; path != "" {
+		reporter, err := middleware.NewFileReporter(path)
+		if err != nil {
+			return nil, err
+		}
+		a.reporter = reporter
+		a.AddShutdownHook("panic reporter", func(ctx context.Context) error {
+			return reporter.Close()
+		})
+	}
+	a.AddShutdownHook("storage", func(ctx context.Context) error {
+		return storage.Close()
+	})
+	return a, nil
 }
 
-func (a *App) Start() {
-	log.SetOutput(os.Stdout)
+func (a *App) AddShutdownHook(name string, fn func(ctx context.Context) error) {
+	a.hooks.add(name, fn)
+}
+
+func (a *App) Liveness() *health.Registry {
+	return a.liveness
+}
+
+func (a *App) Readiness() *health.Registry {
+	return a.readiness
+}
+
+func (a *App) StreamsDone() <-chan struct{} {
+	return a.streamsCtx.Done()
+}
+
+func (a *App) routes() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /todos", a.handler.CreateTask)
-	mux.HandleFunc("GET /todos", a.handler.GetAllTasks)
-	mux.HandleFunc("GET /todos/{id}", a.handler.GetTask)
-	mux.HandleFunc("PUT /todos/{id}", a.handler.UpdateTask)
-	mux.HandleFunc("DELETE /todos/{id}", a.handler.DeleteTask)
-	mux.HandleFunc("DELETE /todos", a.handler.DeleteTasks)
-	loggedMux := middleware.LoggingMiddleware(mux)
-	log.Println("HTTP-Server starting on :8080")
-	if err := http.ListenAndServe(":8080", loggedMux); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	mux.HandleFunc("GET /healthz", health.Handler(a.liveness))
+	mux.HandleFunc("GET /readyz", health.Handler(a.readiness))
+	api := func(pattern string, h http.Handler) {
+		if a.limiter != nil {
+			h = a.limiter.Middleware(h)
+		}
+		mux.Handle(pattern, h)
+	}
+	api("POST /todos", a.authenticated(a.handler.CreateTask))
+	api("GET /todos", a.authenticated(a.handler.GetAllTasks))
+	api("GET /todos/agenda", a.authenticated(a.handler.GetAgenda))
+	api("GET /todos/next", a.authenticated(a.handler.NextTask))
+	api("GET /todos/order", a.authenticated(a.handler.PlanTasks))
+	api("GET /todos/{id}", a.authenticated(a.handler.GetTask))
+	api("PUT /todos/{id}", a.authenticated(a.handler.UpdateTask))
+	api("DELETE /todos/{id}", a.authenticated(a.handler.DeleteTask))
+	api("GET /todos/{id}/children", a.authenticated(a.handler.GetChildren))
+	api("GET /todos/{id}/subtree", a.authenticated(a.handler.GetSubtree))
+	api("PUT /todos/{id}/parent", a.authenticated(a.handler.MoveTask))
+	api("POST /todos/{id}/transitions", a.authenticated(a.handler.TransitionTask))
+	api("GET /todos/{id}/dependencies", a.authenticated(a.handler.GetDependencies))
+	api("POST /todos/{id}/dependencies", a.authenticated(a.handler.AddDependency))
+	api("DELETE /todos/{id}/dependencies/{blocker}", a.authenticated(a.handler.RemoveDependency))
+	api("GET /todos/{id}/comments", a.authenticated(a.handler.ListComments))
+	api("POST /todos/{id}/comments", a.authenticated(a.handler.AddComment))
+	api("PUT /todos/{id}/comments/{comment}", a.authenticated(a.handler.UpdateComment))
+	api("DELETE /todos/{id}/comments/{comment}", a.authenticated(a.handler.DeleteComment))
+	api("DELETE /todos", a.authenticated(a.handler.DeleteTasks))
+	api("GET /tags", a.authenticated(a.handler.ListTags))
+	api("PUT /tags/{tag}", a.authenticated(a.handler.RenameTag))
+	api("GET /projects", a.authenticated(a.handler.ListProjects))
+	api("POST /projects", a.authenticated(a.handler.CreateProject))
+	api("GET /projects/{id}", a.authenticated(a.handler.GetProject))
+	api("PUT /projects/{id}", a.authenticated(a.handler.UpdateProject))
+	api("DELETE /projects/{id}", a.authenticated(a.handler.DeleteProject))
+	api("GET /projects/{id}/todos", a.authenticated(a.handler.GetProjectTasks))
+	api("POST /projects/{id}/todos", a.authenticated(a.handler.CreateProjectTask))
+	api("GET /me/permissions", a.authenticated(a.permissions.GetPermissions))
+	api("POST /admin/keys", a.protect(service.ActionAdminKeys, a.keysHandler.CreateKey))
+	api("GET /admin/keys", a.protect(service.ActionAdminKeys, a.keysHandler.ListKeys))
+	api("DELETE /admin/keys/{id}", a.protect(service.ActionAdminKeys, a.keysHandler.RevokeKey))
+	if a.accounts != nil {
+		api("POST /auth/register", http.HandlerFunc(a.accounts.Register))
+		api("POST /auth/login", http.HandlerFunc(a.accounts.Login))
+		api("POST /auth/logout", http.HandlerFunc(a.accounts.Logout))
+		api("GET /auth/session", http.HandlerFunc(a.accounts.Session))
+	}
+	var handler http.Handler = tenant.Middleware(a.cfg.Tenancy.Header, a.cfg.Tenancy.DefaultTenant, a.tenants.Has)(middleware.OptionsMiddleware(mux))
+	if a.cfg.Auth.Enabled {
+		authenticated := http.NewServeMux()
+		authenticated.Handle("/", auth.Middleware(a.authn...)(handler))
+		if a.accounts != nil {
+			authenticated.Handle("POST /auth/register", handler)
+			authenticated.Handle("POST /auth/login", handler)
+		}
+		handler = authenticated
+	}
+	if corsCfg := a.cfg.CORS; corsCfg.Enabled() {
+		allowedHeaders := corsCfg.AllowedHeaders
+		if !slices.ContainsFunc(allowedHeaders, func(h string) bool { return strings.EqualFold(h, a.cfg.Tenancy.Header) }) {
+			allowedHeaders = append(slices.Clone(allowedHeaders), a.cfg.Tenancy.Header)
+		}
+		handler = middleware.CORSMiddleware(middleware.CORSOptions{
+			AllowedOrigins:   corsCfg.AllowedOrigins,
+			AllowedMethods:   corsCfg.AllowedMethods,
+			AllowedHeaders:   allowedHeaders,
+			ExposedHeaders:   corsCfg.ExposedHeaders,
+			AllowCredentials: corsCfg.AllowCredentials,
+			MaxAge:           corsCfg.MaxAge.Duration,
+		})(handler)
+	}
+	if a.cfg.TLS.ClientCAFile != "" {
+		handler = middleware.ClientCertMiddleware(handler)
+	}
+	if a.cfg.Features.Compression {
+		handler = middleware.CompressMiddleware(a.cfg.Features.CompressionMinSize)(handler)
+	}
+	handler = middleware.RecoveryMiddleware(a.reporter)(handler)
+	if a.cfg.Features.RequestLogging {
+		handler = middleware.LoggingMiddleware(handler)
+	}
+	if a.accessLog != nil {
+		handler = middleware.AccessLogMiddleware(a.accessLog, a.cfg.AccessLog.Format)(handler)
+	}
+	return middleware.RequestIDMiddleware(handler)
+}
+
+func (a *App) setupAuth() error {
+	a.authn = []auth.Authenticator{auth.NewAPIKeyAuthenticator(a.keys)}
+	if a.cfg.Auth.Enabled {
+		sessionsCfg := a.cfg.Auth.Sessions
+		users, err := auth.NewUserStore(sessionsCfg.PBKDF2Iterations)
+		if err != nil {
+			return err
+		}
+		sessions := auth.NewSessionStore(sessionsCfg.TTL.Duration)
+		go auth.CleanupSessions(a.streamsCtx, sessions, time.Minute)
+		a.accounts = handlers.NewAccountsHandler(users, sessions, sessionsCfg.CookieSecure, sessionsCfg.Registration)
+		a.authn = append(a.authn, auth.NewSessionAuthenticator(sessions, users))
+	}
+	jwtCfg := a.cfg.Auth.JWT
+	if !jwtCfg.Enabled() {
+		return nil
+	}
+	var keys auth.KeyProvider
+	if jwtCfg.JWKSFile != "" {
+		jwks, err := auth.NewJWKSFile(jwtCfg.JWKSFile)
+		if err != nil {
+			return err
+		}
+		go jwks.Watch(a.streamsCtx, jwtCfg.ReloadInterval.Duration)
+		keys = jwks
+	} else {
+		keys = auth.NewStaticSecret(jwtCfg.Secret)
+	}
+	verifier := auth.NewJWTVerifier(keys, auth.JWTOptions{
+		Issuer:   jwtCfg.Issuer,
+		Audience: jwtCfg.Audience,
+		Leeway:   jwtCfg.Leeway.Duration,
+	})
+	a.authn = append(a.authn, auth.NewJWTAuthenticator(verifier))
+	return nil
+}
+
+func (a *App) setupRateLimit() error {
+	limitCfg := a.cfg.RateLimit
+	if !limitCfg.Enabled {
+		return nil
+	}
+	proxies, err := middleware.ParseTrustedProxies(limitCfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	a.limiter = middleware.NewRateLimiter(middleware.RateLimiterOptions{
+		Read:           middleware.RateLimit{Limit: limitCfg.ReadLimit, Window: limitCfg.Window.Duration},
+		Write:          middleware.RateLimit{Limit: limitCfg.WriteLimit, Window: limitCfg.Window.Duration},
+		IdleTimeout:    limitCfg.IdleTimeout.Duration,
+		TrustedProxies: proxies,
+	})
+	go a.limiter.Cleanup(a.streamsCtx, limitCfg.IdleTimeout.Duration/2)
+	return nil
+}
+
+func (a *App) authenticated(h http.HandlerFunc) http.Handler {
+	if !a.cfg.Auth.Enabled {
+		return h
+	}
+	return auth.RequireAuthenticated(h)
+}
+
+func (a *App) protect(action string, h http.HandlerFunc) http.Handler {
+	if !a.cfg.Auth.Enabled {
+		return h
+	}
+	return auth.Require(func(p *auth.Principal) bool {
+		return a.authorizer.Allowed(p, action)
+	}, h)
+}
+
+func (a *App) Start() error {
+	log.SetOutput(os.Stdout)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.Run(ctx)
+}
+
+func (a *App) Run(ctx context.Context) error {
+	addr := a.cfg.Server.Addr
+	a.server = &http.Server{
+		Addr:         addr,
+		Handler:      a.routes(),
+		ReadTimeout:  a.cfg.Server.ReadTimeout.Duration,
+		WriteTimeout: a.cfg.Server.WriteTimeout.Duration,
+		IdleTimeout:  a.cfg.Server.IdleTimeout.Duration,
+	}
+	a.server.RegisterOnShutdown(a.closeStreams)
+
+	if a.cfg.TLS.Enabled() {
+		if err := a.setupTLS(); err != nil {
+			return err
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if a.server.TLSConfig != nil {
+			log.Printf("HTTPS-Server starting on %s", addr)
+			serveErr <- a.server.ListenAndServeTLS("", "")
+			return
+		}
+		log.Printf("HTTP-Server starting on %s", addr)
+		serveErr <- a.server.ListenAndServe()
+	}()
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Server failed: %v", err)
+			runErr = err
+		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining connections")
+	}
+
+	if err := a.Shutdown(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+func (a *App) setupTLS() error {
+	reloader, err := certs.NewReloader(a.cfg.TLS.CertFile, a.cfg.TLS.KeyFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := certs.ServerConfig(reloader, a.cfg.TLS.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	a.server.TLSConfig = tlsConfig
+	go reloader.Watch(a.streamsCtx, a.cfg.TLS.ReloadInterval.Duration)
+	return nil
+}
+
+func (a *App) Shutdown() error {
+	a.draining.Store(true)
+	if delay := a.cfg.Server.ShutdownDelay.Duration; a.server != nil && delay > 0 {
+		log.Printf("Reporting not ready for %s before closing the listener", delay)
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout.Duration)
+	defer cancel()
+
+	var shutdownErr error
+	if a.server != nil {
+		if err := a.server.Shutdown(ctx); err != nil {
+			log.Printf("Server shutdown failed: %v", err)
+			shutdownErr = err
+			a.server.Close()
+		}
+	}
+	a.closeStreams()
+	if err := a.hooks.run(ctx); err != nil && shutdownErr == nil {
+		shutdownErr = err
 	}
+	log.Println("HTTP-Server stopped")
+	return shutdownErr
 }
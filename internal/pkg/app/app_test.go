@@ -0,0 +1,395 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/config"
+	"webServerEx/internal/tenant"
+)
+
+func newTestApp(t *testing.T, cfg *config.Config) *App {
+	t.Helper()
+	application, err := NewApp(cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return application
+}
+
+func TestAppShutdown(t *testing.T) {
+	t.Run("hooks run in order", func(t *testing.T) {
+		application := newTestApp(t, config.Default())
+		var order []string
+		application.AddShutdownHook("first", func(ctx context.Context) error {
+			order = append(order, "first")
+			return nil
+		})
+		application.AddShutdownHook("second", func(ctx context.Context) error {
+			order = append(order, "second")
+			return nil
+		})
+
+		if err := application.Shutdown(); err != nil {
+			t.Error(err.Error())
+		}
+		if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+			t.Errorf("uncorrect hooks order: %v", order)
+		}
+	})
+
+	t.Run("hook error is returned", func(t *testing.T) {
+		application := newTestApp(t, config.Default())
+		errHook := errors.New("hook failed")
+		called := false
+		application.AddShutdownHook("broken", func(ctx context.Context) error {
+			return errHook
+		})
+		application.AddShutdownHook("after", func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+
+		err := application.Shutdown()
+		if !errors.Is(err, errHook) {
+			t.Errorf("expected errHook, got %v", err)
+		}
+		if !called {
+			t.Error("hook after failed one was not called")
+		}
+	})
+
+	t.Run("run stops on context cancel", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Addr = "127.0.0.1:0"
+		cfg.Server.ShutdownTimeout.Duration = time.Second
+		application := newTestApp(t, cfg)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- application.Run(ctx)
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err.Error())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not stop")
+		}
+		select {
+		case <-application.StreamsDone():
+		default:
+			t.Error("streams were not closed")
+		}
+	})
+}
+
+func TestAppShutdownDelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	cfg := config.Default()
+	cfg.Server.Addr = addr
+	cfg.Server.ShutdownDelay.Duration = 500 * time.Millisecond
+	application := newTestApp(t, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- application.Run(ctx)
+	}()
+	for range 50 {
+		if resp, err := http.Get("http://" + addr + "/readyz"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://" + addr + "/readyz")
+	if err != nil {
+		t.Fatalf("listener closed before the shutdown delay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status http.StatusServiceUnavailable, got %d", resp.StatusCode)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestAppHealth(t *testing.T) {
+	t.Run("ready until shutdown", func(t *testing.T) {
+		application := newTestApp(t, config.Default())
+		handler := application.routes()
+
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+
+		application.Shutdown()
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status http.StatusServiceUnavailable, got %d", rec.Code)
+		}
+		req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+}
+
+func TestAppAuth(t *testing.T) {
+	t.Run("routes require scoped keys", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Auth.Enabled = true
+		cfg.Auth.AdminKeySHA256 = auth.HashKey("tsk_admin")
+		application := newTestApp(t, cfg)
+		handler := application.routes()
+
+		req := httptest.NewRequest(http.MethodDelete, "/todos", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status http.StatusUnauthorized, got %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"name":"reader","scopes":["tasks:read"]}`))
+		req.Header.Set("Authorization", "Bearer tsk_admin")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+		var created struct {
+			Key string `json:"key"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+
+		req = httptest.NewRequest(http.MethodDelete, "/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+created.Key)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+}
+
+func TestAppSessions(t *testing.T) {
+	t.Run("cookie mutations require csrf token", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Auth.Enabled = true
+		cfg.Auth.AdminKeySHA256 = auth.HashKey("tsk_admin")
+		cfg.Auth.Sessions.PBKDF2Iterations = 10_000
+		handler := newTestApp(t, cfg).routes()
+		credentials := `{"username":"alice","password":"password123"}`
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(credentials))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status http.StatusCreated, got %d", rec.Code)
+		}
+		req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(credentials))
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status http.StatusOK, got %d", rec.Code)
+		}
+		cookie := rec.Result().Cookies()[0]
+		var session struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &session)
+
+		req = httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"test"}`))
+		req.AddCookie(cookie)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"test"}`))
+		req.AddCookie(cookie)
+		req.Header.Set(auth.CSRFHeaderName, session.CSRFToken)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+
+	t.Run("login ignores leftover session cookie", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Auth.Enabled = true
+		cfg.Auth.AdminKeySHA256 = auth.HashKey("tsk_admin")
+		cfg.Auth.Sessions.PBKDF2Iterations = 10_000
+		handler := newTestApp(t, cfg).routes()
+		credentials := `{"username":"alice","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(credentials))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(credentials))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		cookie := rec.Result().Cookies()[0]
+
+		req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(credentials))
+		req.AddCookie(cookie)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+}
+
+func hs256Token(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAppTenancy(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	cfg.Auth.AdminKeySHA256 = auth.HashKey("tsk_admin")
+	cfg.Auth.JWT.Secret = "jwt-secret"
+	cfg.Tenancy.Tenants = []string{"other"}
+	handler := newTestApp(t, cfg).routes()
+	token := hs256Token(t, "jwt-secret", map[string]any{"sub": "alice", "scope": "tasks:write", "exp": time.Now().Add(time.Hour).Unix()})
+
+	tableTests := []struct {
+		name          string
+		authorization string
+		tenant        string
+		status        int
+	}{
+		{name: "tenant-less jwt picks other tenant", authorization: "Bearer " + token, tenant: "other", status: http.StatusForbidden},
+		{name: "tenant-less jwt uses default tenant", authorization: "Bearer " + token, status: http.StatusOK},
+		{name: "admin picks configured tenant", authorization: "Bearer tsk_admin", tenant: "other", status: http.StatusOK},
+		{name: "admin picks unknown tenant", authorization: "Bearer tsk_admin", tenant: "ghost", status: http.StatusNotFound},
+		{name: "anonymous picks unknown tenant", tenant: "ghost", status: http.StatusNotFound},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"test"}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.tenant != "" {
+				req.Header.Set(tenant.DefaultHeader, tt.tenant)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAppRateLimit(t *testing.T) {
+	t.Run("api is limited, probes are not", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.ReadLimit = 1
+		handler := newTestApp(t, cfg).routes()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+		if rec.Code == http.StatusTooManyRequests {
+			t.Error("first request must not be limited")
+		}
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status http.StatusTooManyRequests, got %d", rec.Code)
+		}
+		for range 3 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+			}
+		}
+	})
+}
+
+func TestAppCORS(t *testing.T) {
+	t.Run("preflight and head are handled", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Auth.Enabled = true
+		cfg.Auth.AdminKeySHA256 = auth.HashKey("tsk_admin")
+		cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+		handler := newTestApp(t, cfg).routes()
+
+		req := httptest.NewRequest(http.MethodOptions, "/todos/1", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, x-tenant-id")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+			t.Errorf("uncorrect preflight: %d %v", rec.Code, rec.Header())
+		}
+
+		req = httptest.NewRequest(http.MethodOptions, "/todos/1", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, HEAD, PUT, DELETE, OPTIONS" {
+			t.Errorf("uncorrect options: %d %v", rec.Code, rec.Header())
+		}
+
+		req = httptest.NewRequest(http.MethodHead, "/healthz", nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status http.StatusOK, got %d", rec.Code)
+		}
+	})
+}
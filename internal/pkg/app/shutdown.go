@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type shutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+func (sh *shutdownHooks) add(name string, fn func(ctx context.Context) error) {
+	sh.mu.Lock()
+	sh.hooks = append(sh.hooks, shutdownHook{name: name, fn: fn})
+	sh.mu.Unlock()
+}
+
+func (sh *shutdownHooks) run(ctx context.Context) error {
+	sh.mu.Lock()
+	hooks := make([]shutdownHook, len(sh.hooks))
+	copy(hooks, sh.hooks)
+	sh.mu.Unlock()
+
+	var firstErr error
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			log.Printf("Shutdown hook %q failed: %v", hook.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		log.Printf("Shutdown hook %q finished", hook.name)
+	}
+	return firstErr
+}
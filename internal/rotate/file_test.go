@@ -0,0 +1,130 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	t.Run("rotates by size and compresses", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "access.log")
+		f, err := Open(path, Options{MaxSize: 10, Compress: true})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		f.Write([]byte("0123456789"))
+		f.Write([]byte("abc"))
+		if err := f.Close(); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		current, _ := os.ReadFile(path)
+		if string(current) != "abc" {
+			t.Errorf("uncorrect current file: %q", current)
+		}
+		archives, _ := filepath.Glob(path + ".*.gz")
+		if len(archives) != 1 {
+			t.Fatalf("expected 1 archive, got %v", archives)
+		}
+		archive, _ := os.Open(archives[0])
+		defer archive.Close()
+		zr, err := gzip.NewReader(archive)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		data, _ := io.ReadAll(zr)
+		if string(data) != "0123456789" {
+			t.Errorf("uncorrect archive: %q", data)
+		}
+	})
+
+	t.Run("rotates by age", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		now := time.Now()
+		f, err := Open(path, Options{MaxAge: time.Hour, Now: func() time.Time { return now }})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		f.Write([]byte("old\n"))
+		now = now.Add(time.Hour)
+		f.Write([]byte("new\n"))
+		f.Close()
+
+		rotated, _ := filepath.Glob(path + ".*")
+		if len(rotated) != 1 {
+			t.Fatalf("expected 1 rotated file, got %v", rotated)
+		}
+		data, _ := os.ReadFile(rotated[0])
+		if string(data) != "old\n" {
+			t.Errorf("uncorrect rotated file: %q", data)
+		}
+	})
+
+	t.Run("rotates existing file by modification time", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+			t.Fatal(err.Error())
+		}
+		modified := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err.Error())
+		}
+		f, err := Open(path, Options{MaxAge: time.Hour})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		f.Write([]byte("new\n"))
+		f.Close()
+
+		rotated, _ := filepath.Glob(path + ".*")
+		if len(rotated) != 1 {
+			t.Fatalf("expected 1 rotated file, got %v", rotated)
+		}
+		current, _ := os.ReadFile(path)
+		if string(current) != "new\n" {
+			t.Errorf("uncorrect current file: %q", current)
+		}
+	})
+
+	t.Run("keeps writing after failed rename", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		now := time.Now()
+		f, err := Open(path, Options{Now: func() time.Time { return now }})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer f.Close()
+		f.Write([]byte("old\n"))
+		blocker := path + "." + now.UTC().Format("20060102T150405.000000000")
+		if err := os.MkdirAll(filepath.Join(blocker, "busy"), 0o755); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := f.Rotate(); err == nil {
+			t.Fatal("expected rename error, got nil")
+		}
+		if _, err := f.Write([]byte("new\n")); err != nil {
+			t.Fatalf("expected write to succeed, got %v", err)
+		}
+		current, _ := os.ReadFile(path)
+		if string(current) != "old\nnew\n" {
+			t.Errorf("uncorrect current file: %q", current)
+		}
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		f, err := Open(filepath.Join(t.TempDir(), "access.log"), Options{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		f.Close()
+		if _, err := f.Write([]byte("late")); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+}
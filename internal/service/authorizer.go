@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"webServerEx/internal/auth"
+)
+
+const (
+	ActionTasksRead      = "tasks:read"
+	ActionTasksCreate    = "tasks:create"
+	ActionTasksUpdate    = "tasks:update"
+	ActionTasksDelete    = "tasks:delete"
+	ActionTasksDeleteAll = "tasks:delete_all"
+	ActionTasksManageAll = "tasks:manage_all"
+	ActionProjectsRead   = "projects:read"
+	ActionProjectsWrite  = "projects:write"
+	ActionTagsManage     = "tags:manage"
+	ActionCommentsRead   = "comments:read"
+	ActionCommentsWrite  = "comments:write"
+	ActionAdminKeys      = "admin:keys"
+)
+
+var Actions = []string{
+	ActionTasksRead,
+	ActionTasksCreate,
+	ActionTasksUpdate,
+	ActionTasksDelete,
+	ActionTasksDeleteAll,
+	ActionTasksManageAll,
+	ActionProjectsRead,
+	ActionProjectsWrite,
+	ActionTagsManage,
+	ActionCommentsRead,
+	ActionCommentsWrite,
+	ActionAdminKeys,
+}
+
+var (
+	ErrForbidden     = errors.New("action is not allowed")
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+type Policy map[string][]string
+
+func DefaultPolicy() Policy {
+	return Policy{
+		auth.RoleViewer: {ActionTasksRead, ActionProjectsRead, ActionCommentsRead},
+		auth.RoleEditor: {ActionTasksRead, ActionTasksCreate, ActionTasksUpdate, ActionTasksDelete, ActionProjectsRead, ActionProjectsWrite, ActionCommentsRead, ActionCommentsWrite},
+		auth.RoleAdmin:  {"*"},
+	}
+}
+
+type Authorizer struct {
+	policy Policy
+}
+
+func NewAuthorizer(overrides Policy) (*Authorizer, error) {
+	policy := DefaultPolicy()
+	for role, actions := range overrides {
+		if strings.TrimSpace(role) == "" {
+			return nil, fmt.Errorf("%w: empty role name", ErrInvalidPolicy)
+		}
+		for _, action := range actions {
+			if !knownAction(action) {
+				return nil, fmt.Errorf("%w: role %s has unknown action %q", ErrInvalidPolicy, role, action)
+			}
+		}
+		policy[role] = actions
+	}
+	return &Authorizer{policy: policy}, nil
+}
+
+func knownAction(action string) bool {
+	if action == "*" || slices.Contains(Actions, action) {
+		return true
+	}
+	prefix, found := strings.CutSuffix(action, ":*")
+	if !found {
+		return false
+	}
+	for _, known := range Actions {
+		if strings.HasPrefix(known, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func grants(pattern, action string) bool {
+	if pattern == "*" || pattern == action {
+		return true
+	}
+	prefix, found := strings.CutSuffix(pattern, "*")
+	return found && strings.HasPrefix(action, prefix)
+}
+
+func (a *Authorizer) Allowed(p *auth.Principal, action string) bool {
+	if p == nil {
+		return true
+	}
+	for _, role := range p.EffectiveRoles() {
+		for _, pattern := range a.policy[role] {
+			if grants(pattern, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, action string) error {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !a.Allowed(principal, action) {
+		return fmt.Errorf("%w: %s", ErrForbidden, action)
+	}
+	return nil
+}
+
+func (a *Authorizer) Permissions(p *auth.Principal) map[string]bool {
+	permissions := make(map[string]bool, len(Actions))
+	for _, action := range Actions {
+		permissions[action] = a.Allowed(p, action)
+	}
+	return permissions
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"webServerEx/internal/auth"
+)
+
+func TestAuthorizerAllowed(t *testing.T) {
+	authorizer, err := NewAuthorizer(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	viewer := &auth.Principal{Roles: []string{auth.RoleViewer}}
+	editor := &auth.Principal{Roles: []string{auth.RoleEditor}}
+	admin := &auth.Principal{Roles: []string{auth.RoleAdmin}}
+	writeKey := &auth.Principal{Scopes: []string{auth.ScopeTasksWrite}}
+
+	tableTests := []struct {
+		name      string
+		principal *auth.Principal
+		action    string
+		allowed   bool
+	}{
+		{name: "viewer reads", principal: viewer, action: ActionTasksRead, allowed: true},
+		{name: "viewer creates", principal: viewer, action: ActionTasksCreate, allowed: false},
+		{name: "editor updates", principal: editor, action: ActionTasksUpdate, allowed: true},
+		{name: "editor deletes all", principal: editor, action: ActionTasksDeleteAll, allowed: false},
+		{name: "editor manages keys", principal: editor, action: ActionAdminKeys, allowed: false},
+		{name: "admin manages keys", principal: admin, action: ActionAdminKeys, allowed: true},
+		{name: "write scope maps to editor", principal: writeKey, action: ActionTasksCreate, allowed: true},
+		{name: "no roles", principal: &auth.Principal{}, action: ActionTasksRead, allowed: false},
+		{name: "auth disabled", principal: nil, action: ActionTasksDeleteAll, allowed: true},
+	}
+	for _, tt := range tableTests {
+		if got := authorizer.Allowed(tt.principal, tt.action); got != tt.allowed {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.allowed, got)
+		}
+	}
+}
+
+func TestAuthorizerPolicy(t *testing.T) {
+	t.Run("override role policy", func(t *testing.T) {
+		authorizer, err := NewAuthorizer(Policy{
+			auth.RoleViewer: {"tasks:*"},
+			"auditor":       {ActionTasksRead, ActionTasksManageAll},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		viewer := &auth.Principal{Roles: []string{auth.RoleViewer}}
+		if !authorizer.Allowed(viewer, ActionTasksDeleteAll) {
+			t.Error("expected wildcard to grant tasks:delete_all")
+		}
+		if authorizer.Allowed(viewer, ActionProjectsRead) {
+			t.Error("override must replace default viewer policy")
+		}
+		permissions := authorizer.Permissions(&auth.Principal{Roles: []string{"auditor"}})
+		if !permissions[ActionTasksManageAll] || permissions[ActionTasksCreate] {
+			t.Errorf("uncorrect permissions: %v", permissions)
+		}
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := NewAuthorizer(Policy{"viewer": {"tasks:fly"}})
+		if !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("expected ErrInvalidPolicy, got %v", err)
+		}
+		_, err = NewAuthorizer(Policy{"viewer": {"billing:*"}})
+		if !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("expected ErrInvalidPolicy, got %v", err)
+		}
+	})
+
+	t.Run("authorize from context", func(t *testing.T) {
+		authorizer, _ := NewAuthorizer(nil)
+		ctx := auth.WithPrincipal(testContext(), &auth.Principal{Roles: []string{auth.RoleViewer}})
+
+		if err := authorizer.Authorize(ctx, ActionTasksCreate); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
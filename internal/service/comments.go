@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/entity"
+)
+
+const (
+	maxCommentLength     = 10000
+	defaultCommentsLimit = 20
+	maxCommentsLimit     = 100
+)
+
+var (
+	ErrInvalidComment  = errors.New("invalid comment")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrInvalidPage     = errors.New("invalid page")
+)
+
+type CommentsPage struct {
+	Comments []*entity.Comment `json:"comments"`
+	Total    int               `json:"total"`
+	Offset   int               `json:"offset"`
+	Limit    int               `json:"limit"`
+}
+
+func validateComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxCommentLength {
+		return body, ErrInvalidComment
+	}
+	return body, nil
+}
+
+func (r *tasksService) authored(ctx context.Context, comment *entity.Comment) bool {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	return !ok || comment.AuthorID == principal.Subject()
+}
+
+func (r *tasksService) commentTask(ctx context.Context, repository Repository, id string) (*entity.Task, error) {
+	taskID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return r.ownedTask(ctx, repository, taskID)
+}
+
+func (r *tasksService) taskComment(ctx context.Context, repository Repository, taskID, id string) (*entity.Comment, error) {
+	task, err := r.commentTask(ctx, repository, taskID)
+	if err != nil {
+		return nil, err
+	}
+	commentID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	comment, err := repository.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.TaskID != task.ID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+func (r *tasksService) ListComments(ctx context.Context, taskID string, offset, limit int) (*CommentsPage, error) {
+	if err := r.authorizer.Authorize(ctx, ActionCommentsRead); err != nil {
+		log.Printf("---Service: failed to list comments: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to list comments: %v", err)
+		return nil, err
+	}
+	if limit == 0 {
+		limit = defaultCommentsLimit
+	}
+	if offset < 0 || limit < 0 || limit > maxCommentsLimit {
+		log.Printf("---Service: failed to list comments: %v", ErrInvalidPage)
+		return nil, ErrInvalidPage
+	}
+	task, err := r.commentTask(ctx, repository, taskID)
+	if err != nil {
+		log.Printf("---Service: failed to list comments: %v", err)
+		return nil, err
+	}
+	comments, total, err := repository.ListComments(task.ID, offset, limit)
+	if err != nil {
+		log.Printf("---Service: failed to list comments from repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: comments listed successfully")
+	return &CommentsPage{Comments: comments, Total: total, Offset: offset, Limit: limit}, nil
+}
+
+func (r *tasksService) AddComment(ctx context.Context, taskID, body string) (*entity.Comment, error) {
+	if err := r.authorizer.Authorize(ctx, ActionCommentsWrite); err != nil {
+		log.Printf("---Service: failed to add comment: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to add comment: %v", err)
+		return nil, err
+	}
+	if body, err = validateComment(body); err != nil {
+		log.Printf("---Service: failed to add comment: %v", err)
+		return nil, err
+	}
+	task, err := r.commentTask(ctx, repository, taskID)
+	if err != nil {
+		log.Printf("---Service: failed to add comment: %v", err)
+		return nil, err
+	}
+	now := r.timestamp()
+	comment := &entity.Comment{
+		TaskID:    task.ID,
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		comment.AuthorID = principal.Subject()
+		comment.Author = principal.Name
+	}
+	if err = repository.AddComment(comment); err != nil {
+		log.Printf("---Service: failed to add comment to repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: comment added successfully")
+	return comment, nil
+}
+
+func (r *tasksService) UpdateComment(ctx context.Context, taskID, id, body string) (*entity.Comment, error) {
+	if err := r.authorizer.Authorize(ctx, ActionCommentsWrite); err != nil {
+		log.Printf("---Service: failed to update comment: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to update comment: %v", err)
+		return nil, err
+	}
+	if body, err = validateComment(body); err != nil {
+		log.Printf("---Service: failed to update comment: %v", err)
+		return nil, err
+	}
+	current, err := r.taskComment(ctx, repository, taskID, id)
+	if err != nil {
+		log.Printf("---Service: failed to update comment: %v", err)
+		return nil, err
+	}
+	if !r.authored(ctx, current) {
+		err = fmt.Errorf("%w: only the author can edit a comment", ErrForbidden)
+		log.Printf("---Service: failed to update comment: %v", err)
+		return nil, err
+	}
+	comment := &entity.Comment{
+		TaskID:    current.TaskID,
+		Body:      body,
+		AuthorID:  current.AuthorID,
+		Author:    current.Author,
+		CreatedAt: current.CreatedAt,
+		UpdatedAt: r.timestamp(),
+	}
+	if err = repository.UpdateComment(current.ID, comment); err != nil {
+		log.Printf("---Service: failed to update comment in repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: comment updated successfully")
+	return comment, nil
+}
+
+func (r *tasksService) DeleteComment(ctx context.Context, taskID, id string) error {
+	if err := r.authorizer.Authorize(ctx, ActionCommentsWrite); err != nil {
+		log.Printf("---Service: failed to delete comment: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to delete comment: %v", err)
+		return err
+	}
+	comment, err := r.taskComment(ctx, repository, taskID, id)
+	if err != nil {
+		log.Printf("---Service: failed to delete comment: %v", err)
+		return err
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !r.authored(ctx, comment) && !r.authorizer.Allowed(principal, ActionTasksManageAll) {
+		err = fmt.Errorf("%w: only the author can delete a comment", ErrForbidden)
+		log.Printf("---Service: failed to delete comment: %v", err)
+		return err
+	}
+	if err = repository.DeleteComment(comment.ID); err != nil {
+		log.Printf("---Service: failed to delete comment from repository: %v", err)
+		return err
+	}
+	log.Println("---Service: comment deleted successfully")
+	return nil
+}
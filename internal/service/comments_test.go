@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestServiceComments(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	bob := principalContext("bob", auth.ScopeTasksWrite)
+	admin := principalContext("admin", auth.ScopeTasksAdmin)
+	newService := func() Service {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "review"})
+		return service
+	}
+
+	t.Run("author attribution", func(t *testing.T) {
+		service := newService()
+		comment, err := service.AddComment(alice, "0", "  looks good  ")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if comment.AuthorID != "session:alice" || comment.Body != "looks good" || comment.CreatedAt.IsZero() {
+			t.Errorf("uncorrect comment: %+v", comment)
+		}
+	})
+
+	t.Run("invalid comment", func(t *testing.T) {
+		service := newService()
+		for _, body := range []string{" ", strings.Repeat("a", maxCommentLength+1)} {
+			if _, err := service.AddComment(alice, "0", body); !errors.Is(err, ErrInvalidComment) {
+				t.Errorf("expected ErrInvalidComment, got %v", err)
+			}
+		}
+	})
+
+	t.Run("comments of foreign task", func(t *testing.T) {
+		service := newService()
+		if _, err := service.AddComment(bob, "0", "hi"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+		if _, err := service.ListComments(bob, "0", 0, 0); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		service := newService()
+		for _, body := range []string{"first", "second", "third"} {
+			service.AddComment(alice, "0", body)
+		}
+		page, err := service.ListComments(alice, "0", 2, 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if page.Total != 3 || page.Limit != defaultCommentsLimit || len(page.Comments) != 1 || page.Comments[0].Body != "third" {
+			t.Errorf("uncorrect page: %+v", page)
+		}
+		if _, err = service.ListComments(alice, "0", 0, maxCommentsLimit+1); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("expected ErrInvalidPage, got %v", err)
+		}
+		if _, err = service.ListComments(alice, "0", -1, 0); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("expected ErrInvalidPage, got %v", err)
+		}
+	})
+
+	t.Run("edit by author", func(t *testing.T) {
+		service := newService()
+		service.AddComment(alice, "0", "first")
+		comment, err := service.UpdateComment(alice, "0", "0", "edited")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if comment.Body != "edited" || comment.AuthorID != "session:alice" {
+			t.Errorf("uncorrect comment: %+v", comment)
+		}
+		if _, err = service.UpdateComment(admin, "0", "0", "hijacked"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		service := newService()
+		service.AddComment(alice, "0", "first")
+		service.AddComment(admin, "0", "second")
+		if err := service.DeleteComment(alice, "0", "1"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		if err := service.DeleteComment(admin, "0", "0"); err != nil {
+			t.Errorf("expected moderator delete, got %v", err)
+		}
+		if err := service.DeleteComment(alice, "0", "0"); !errors.Is(err, inmemory.ErrCommentNotFound) {
+			t.Errorf("expected ErrCommentNotFound, got %v", err)
+		}
+	})
+
+	t.Run("comment of another task", func(t *testing.T) {
+		service := newService()
+		service.AddTask(alice, TaskInput{Title: "deploy"})
+		service.AddComment(alice, "0", "first")
+		if _, err := service.UpdateComment(alice, "1", "0", "moved"); !errors.Is(err, ErrCommentNotFound) {
+			t.Errorf("expected ErrCommentNotFound, got %v", err)
+		}
+	})
+
+	t.Run("cascade on task delete", func(t *testing.T) {
+		service := newService()
+		service.AddComment(alice, "0", "first")
+		if err := service.DeleteTask(alice, "0"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := service.ListComments(alice, "0", 0, 0); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+		if err := service.DeleteComment(alice, "0", "0"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("viewer cannot comment", func(t *testing.T) {
+		service := newService()
+		viewer := principalContext("alice", auth.ScopeTasksRead)
+		if _, err := service.AddComment(viewer, "0", "hi"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		if _, err := service.ListComments(viewer, "0", 0, 0); err != nil {
+			t.Errorf("expected viewer to read comments, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"webServerEx/internal/entity"
+)
+
+var ErrTaskBlocked = errors.New("task is blocked by unfinished tasks")
+
+type Dependencies struct {
+	BlockedBy []*entity.Task `json:"blocked_by"`
+	Blocks    []*entity.Task `json:"blocks"`
+}
+
+func (r *tasksService) blocked(repository Repository, task *entity.Task) bool {
+	return r.blockedOutside(repository, task, nil)
+}
+
+func (r *tasksService) blockedOutside(repository Repository, task *entity.Task, closing map[uint64]bool) bool {
+	for _, blockerID := range task.BlockedBy {
+		if closing[blockerID] {
+			continue
+		}
+		if blocker, err := repository.Get(blockerID); err == nil && !blocker.Finished {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *tasksService) dependencyIDs(ctx context.Context, repository Repository, id, blockerID string) (uint64, uint64, error) {
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	correctBlockerID, err := strconv.ParseUint(blockerID, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	if _, err = r.ownedTask(ctx, repository, correctID); err != nil {
+		return 0, 0, err
+	}
+	if _, err = r.ownedTask(ctx, repository, correctBlockerID); err != nil {
+		return 0, 0, err
+	}
+	return correctID, correctBlockerID, nil
+}
+
+func (r *tasksService) GetDependencies(ctx context.Context, id string) (*Dependencies, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get dependencies: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get dependencies: %v", err)
+		return nil, err
+	}
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Printf("---Service: failed to get dependencies: %v", ErrInvalidID)
+		return nil, ErrInvalidID
+	}
+	task, err := r.ownedTask(ctx, repository, correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get dependencies: %v", err)
+		return nil, err
+	}
+	dependencies := &Dependencies{BlockedBy: []*entity.Task{}, Blocks: []*entity.Task{}}
+	for _, blockerID := range task.BlockedBy {
+		if blocker, err := repository.Get(blockerID); err == nil && r.canAccess(ctx, blocker) {
+			dependencies.BlockedBy = append(dependencies.BlockedBy, blocker)
+		}
+	}
+	dependents, err := repository.Dependents(correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get dependencies from repository: %v", err)
+		return nil, err
+	}
+	for _, dependent := range dependents {
+		if r.canAccess(ctx, dependent) {
+			dependencies.Blocks = append(dependencies.Blocks, dependent)
+		}
+	}
+	slices.SortFunc(dependencies.Blocks, func(a, b *entity.Task) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	log.Println("---Service: dependencies got successfully")
+	return dependencies, nil
+}
+
+func (r *tasksService) AddDependency(ctx context.Context, id, blockerID string) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksUpdate); err != nil {
+		log.Printf("---Service: failed to add dependency: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to add dependency: %v", err)
+		return err
+	}
+	correctID, correctBlockerID, err := r.dependencyIDs(ctx, repository, id, blockerID)
+	if err != nil {
+		log.Printf("---Service: failed to add dependency: %v", err)
+		return err
+	}
+	if err = repository.AddDependency(correctID, correctBlockerID, r.timestamp()); err != nil {
+		log.Printf("---Service: failed to add dependency to repository: %v", err)
+		return err
+	}
+	log.Println("---Service: dependency added successfully")
+	return nil
+}
+
+func (r *tasksService) RemoveDependency(ctx context.Context, id, blockerID string) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksUpdate); err != nil {
+		log.Printf("---Service: failed to remove dependency: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to remove dependency: %v", err)
+		return err
+	}
+	correctID, correctBlockerID, err := r.dependencyIDs(ctx, repository, id, blockerID)
+	if err != nil {
+		log.Printf("---Service: failed to remove dependency: %v", err)
+		return err
+	}
+	if err = repository.RemoveDependency(correctID, correctBlockerID, r.timestamp()); err != nil {
+		log.Printf("---Service: failed to remove dependency from repository: %v", err)
+		return err
+	}
+	log.Println("---Service: dependency removed successfully")
+	return nil
+}
+
+func (r *tasksService) PlanTasks(ctx context.Context, filter TaskFilter) ([]*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to plan tasks: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to plan tasks: %v", err)
+		return nil, err
+	}
+	if filter.Sort == "" {
+		filter.Sort = SortPriority
+	}
+	tasks, err := r.visibleTasks(ctx, repository, filter)
+	if err != nil {
+		log.Printf("---Service: failed to plan tasks from repository: %v", err)
+		return nil, err
+	}
+	tasks = slices.DeleteFunc(tasks, func(task *entity.Task) bool {
+		return task.Finished
+	})
+	log.Println("---Service: tasks planned successfully")
+	return topologicalOrder(tasks), nil
+}
+
+func topologicalOrder(tasks []*entity.Task) []*entity.Task {
+	included := make(map[uint64]bool, len(tasks))
+	for _, task := range tasks {
+		included[task.ID] = true
+	}
+	pending := make([]int, len(tasks))
+	dependents := make(map[uint64][]int)
+	for i, task := range tasks {
+		for _, blockerID := range task.BlockedBy {
+			if included[blockerID] {
+				pending[i]++
+				dependents[blockerID] = append(dependents[blockerID], i)
+			}
+		}
+	}
+	var ready []int
+	for i := range tasks {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make([]*entity.Task, 0, len(tasks))
+	for len(ready) > 0 {
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, tasks[next])
+		for _, dependent := range dependents[tasks[next].ID] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				position, _ := slices.BinarySearch(ready, dependent)
+				ready = slices.Insert(ready, position, dependent)
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestServiceDependencies(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	newService := func() Service {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "deploy", Priority: "P0"})
+		service.AddTask(alice, TaskInput{Title: "build", Priority: "P2"})
+		service.AddTask(alice, TaskInput{Title: "test", Priority: "P1"})
+		service.AddDependency(alice, "0", "2")
+		service.AddDependency(alice, "2", "1")
+		return service
+	}
+
+	t.Run("reject cycles", func(t *testing.T) {
+		service := newService()
+		if err := service.AddDependency(alice, "1", "0"); !errors.Is(err, inmemory.ErrDependencyCycle) {
+			t.Errorf("expected ErrDependencyCycle, got %v", err)
+		}
+		if err := service.AddDependency(alice, "1", "1"); !errors.Is(err, inmemory.ErrDependencyCycle) {
+			t.Errorf("expected ErrDependencyCycle, got %v", err)
+		}
+	})
+
+	t.Run("dependencies of task", func(t *testing.T) {
+		service := newService()
+		dependencies, err := service.GetDependencies(alice, "2")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(dependencies.BlockedBy) != 1 || dependencies.BlockedBy[0].Title != "build" ||
+			len(dependencies.Blocks) != 1 || dependencies.Blocks[0].Title != "deploy" {
+			t.Errorf("uncorrect dependencies: %+v", dependencies)
+		}
+	})
+
+	t.Run("blocked filter and finishing", func(t *testing.T) {
+		service := newService()
+		unblocked := false
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{Blocked: &unblocked})
+		if len(tasks) != 1 || tasks[0].Title != "build" {
+			t.Errorf("uncorrect unblocked tasks: %v", tasks)
+		}
+		if err := service.UpdateTask(alice, "2", TaskInput{Title: "test", Finished: true}); !errors.Is(err, ErrTaskBlocked) {
+			t.Errorf("expected ErrTaskBlocked, got %v", err)
+		}
+		if err := service.UpdateTask(alice, "2", TaskInput{Title: "test", Finished: true, Force: true}); err != nil {
+			t.Errorf("expected forced finish, got %v", err)
+		}
+	})
+
+	t.Run("topological order", func(t *testing.T) {
+		service := newService()
+		service.AddTask(alice, TaskInput{Title: "docs", Priority: "P3"})
+		tasks, err := service.PlanTasks(alice, TaskFilter{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		if len(titles) != 4 || titles[0] != "build" || titles[1] != "test" || titles[2] != "deploy" || titles[3] != "docs" {
+			t.Errorf("uncorrect order: %v", titles)
+		}
+	})
+
+	t.Run("plan of empty storage", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		tasks, err := service.PlanTasks(alice, TaskFilter{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if tasks == nil || len(tasks) != 0 {
+			t.Errorf("uncorrect plan: %v", tasks)
+		}
+	})
+
+	t.Run("deleting blocker removes edge", func(t *testing.T) {
+		service := newService()
+		if err := service.DeleteTask(alice, "1"); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "2")
+		if len(task.BlockedBy) != 0 {
+			t.Errorf("uncorrect blocked_by: %v", task.BlockedBy)
+		}
+		if err := service.RemoveDependency(alice, "0", "1"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+}
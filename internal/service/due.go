@@ -0,0 +1,113 @@
+package service
+
+import (
+	"time"
+	"webServerEx/internal/entity"
+)
+
+const (
+	DueToday    = "today"
+	DueTomorrow = "tomorrow"
+	DueWeek     = "week"
+)
+
+type Agenda struct {
+	Overdue  []*entity.Task `json:"overdue"`
+	Today    []*entity.Task `json:"today"`
+	ThisWeek []*entity.Task `json:"this_week"`
+	Later    []*entity.Task `json:"later"`
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+func startOfWeek(t time.Time, loc *time.Location) time.Time {
+	day := startOfDay(t, loc)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func dueDay(task *entity.Task, loc *time.Location) time.Time {
+	if task.DueAllDay {
+		y, m, d := task.DueAt.UTC().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	return startOfDay(*task.DueAt, loc)
+}
+
+func dueStart(task *entity.Task, loc *time.Location) time.Time {
+	if task.DueAllDay {
+		return dueDay(task, loc)
+	}
+	return *task.DueAt
+}
+
+func deadline(task *entity.Task, loc *time.Location) time.Time {
+	if task.DueAllDay {
+		return dueDay(task, loc).AddDate(0, 0, 1)
+	}
+	return *task.DueAt
+}
+
+func Overdue(task *entity.Task, now time.Time, loc *time.Location) bool {
+	return !task.Finished && task.DueAt != nil && !now.Before(deadline(task, loc))
+}
+
+func dueWithin(task *entity.Task, due string, now time.Time, loc *time.Location) bool {
+	if task.DueAt == nil {
+		return false
+	}
+	day := dueDay(task, loc)
+	today := startOfDay(now, loc)
+	switch due {
+	case DueToday:
+		return day.Equal(today)
+	case DueTomorrow:
+		return day.Equal(today.AddDate(0, 0, 1))
+	case DueWeek:
+		week := startOfWeek(now, loc)
+		return !day.Before(week) && day.Before(week.AddDate(0, 0, 7))
+	}
+	return false
+}
+
+func ValidDue(due string) bool {
+	switch due {
+	case DueToday, DueTomorrow, DueWeek:
+		return true
+	}
+	return false
+}
+
+func buildAgenda(tasks []*entity.Task, now time.Time, loc *time.Location) *Agenda {
+	agenda := &Agenda{
+		Overdue:  []*entity.Task{},
+		Today:    []*entity.Task{},
+		ThisWeek: []*entity.Task{},
+		Later:    []*entity.Task{},
+	}
+	today := startOfDay(now, loc)
+	weekEnd := startOfWeek(now, loc).AddDate(0, 0, 7)
+	for _, task := range tasks {
+		if task.Finished || task.DueAt == nil {
+			continue
+		}
+		day := dueDay(task, loc)
+		switch {
+		case Overdue(task, now, loc):
+			agenda.Overdue = append(agenda.Overdue, task)
+		case day.Equal(today):
+			agenda.Today = append(agenda.Today, task)
+		case day.Before(weekEnd):
+			agenda.ThisWeek = append(agenda.ThisWeek, task)
+		default:
+			agenda.Later = append(agenda.Later, task)
+		}
+	}
+	for _, group := range [][]*entity.Task{agenda.Overdue, agenda.Today, agenda.ThisWeek, agenda.Later} {
+		sortTasks(group, SortDue, loc)
+	}
+	return agenda
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestServiceDue(t *testing.T) {
+	// Wednesday 2024-03-06 23:30 UTC is already Thursday in Moscow.
+	now := time.Date(2024, time.March, 6, 23, 30, 0, 0, time.UTC)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithClock(func() time.Time { return now }))
+	ctx := testContext()
+	day := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	at := time.Date(2024, time.March, 6, 22, 0, 0, 0, time.UTC)
+
+	inputs := []TaskInput{
+		{Title: "all day wednesday", DueAt: day(2024, time.March, 6), DueAllDay: true},
+		{Title: "all day thursday", DueAt: day(2024, time.March, 7), DueAllDay: true},
+		{Title: "timed", DueAt: &at},
+		{Title: "sunday", DueAt: day(2024, time.March, 10), DueAllDay: true},
+		{Title: "next month", DueAt: day(2024, time.April, 1), DueAllDay: true},
+		{Title: "no due"},
+	}
+	for _, input := range inputs {
+		if err := service.AddTask(ctx, input); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		notMidnight := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+		for _, input := range []TaskInput{
+			{Title: "all day with time", DueAt: &notMidnight, DueAllDay: true},
+			{Title: "all day without date", DueAllDay: true},
+			{Title: "ancient", DueAt: day(1066, time.October, 14)},
+		} {
+			if err := service.AddTask(ctx, input); err != ErrInvalidDue {
+				t.Errorf("%s: expected ErrInvalidDue, got %v", input.Title, err)
+			}
+		}
+	})
+
+	t.Run("filters depend on timezone", func(t *testing.T) {
+		tableTests := []struct {
+			name     string
+			filter   TaskFilter
+			expected int
+		}{
+			{name: "today in UTC", filter: TaskFilter{Due: DueToday}, expected: 2},
+			{name: "today in Moscow", filter: TaskFilter{Due: DueToday, Location: moscow}, expected: 2},
+			{name: "tomorrow in Moscow", filter: TaskFilter{Due: DueTomorrow, Location: moscow}, expected: 0},
+			{name: "overdue in UTC", filter: TaskFilter{Overdue: true}, expected: 1},
+			{name: "overdue in Moscow", filter: TaskFilter{Overdue: true, Location: moscow}, expected: 2},
+			{name: "this week", filter: TaskFilter{Due: DueWeek}, expected: 4},
+			{name: "due before", filter: TaskFilter{DueBefore: *day(2024, time.March, 7)}, expected: 2},
+		}
+		for _, tt := range tableTests {
+			t.Run(tt.name, func(t *testing.T) {
+				tasks, err := service.GetAllTasks(ctx, tt.filter)
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+				if len(tasks) != tt.expected {
+					t.Errorf("expected %d tasks, got %d", tt.expected, len(tasks))
+				}
+			})
+		}
+	})
+
+	t.Run("agenda", func(t *testing.T) {
+		agenda, err := service.GetAgenda(ctx, moscow)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(agenda.Overdue) != 2 || len(agenda.Today) != 1 || len(agenda.ThisWeek) != 1 || len(agenda.Later) != 1 {
+			t.Fatalf("uncorrect agenda: %d %d %d %d", len(agenda.Overdue), len(agenda.Today), len(agenda.ThisWeek), len(agenda.Later))
+		}
+		if agenda.Overdue[0].Title != "all day wednesday" || agenda.Today[0].Title != "all day thursday" {
+			t.Errorf("uncorrect agenda order: %s %s", agenda.Overdue[0].Title, agenda.Today[0].Title)
+		}
+	})
+	t.Run("agenda of empty storage", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		agenda, err := service.GetAgenda(ctx, moscow)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(agenda.Overdue) != 0 || len(agenda.Today) != 0 || len(agenda.ThisWeek) != 0 || len(agenda.Later) != 0 {
+			t.Errorf("uncorrect agenda: %v", agenda)
+		}
+	})
+}
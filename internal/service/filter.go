@@ -0,0 +1,84 @@
+package service
+
+import (
+	"slices"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+type TaskFilter struct {
+	CreatedSince   time.Time
+	UpdatedSince   time.Time
+	CompletedSince time.Time
+	DueBefore      time.Time
+	DueAfter       time.Time
+	Due            string
+	Overdue        bool
+	Priorities     []string
+	Tags           []string
+	AnyTag         bool
+	Project        *uint64
+	Blocked        *bool
+	Series         *uint64
+	Statuses       []string
+	Sort           string
+	Location       *time.Location
+}
+
+func (f TaskFilter) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+func (f TaskFilter) Match(task *entity.Task, now time.Time) bool {
+	if !f.CreatedSince.IsZero() && task.CreatedAt.Before(f.CreatedSince) {
+		return false
+	}
+	if !f.UpdatedSince.IsZero() && task.UpdatedAt.Before(f.UpdatedSince) {
+		return false
+	}
+	if !f.CompletedSince.IsZero() && (task.CompletedAt == nil || task.CompletedAt.Before(f.CompletedSince)) {
+		return false
+	}
+	loc := f.location()
+	if !f.DueBefore.IsZero() && (task.DueAt == nil || !dueStart(task, loc).Before(f.DueBefore)) {
+		return false
+	}
+	if !f.DueAfter.IsZero() && (task.DueAt == nil || dueStart(task, loc).Before(f.DueAfter)) {
+		return false
+	}
+	if f.Due != "" && !dueWithin(task, f.Due, now, loc) {
+		return false
+	}
+	if f.Overdue && !Overdue(task, now, loc) {
+		return false
+	}
+	if len(f.Priorities) > 0 && !slices.Contains(f.Priorities, task.Priority) {
+		return false
+	}
+	if f.Project != nil && task.ProjectID != *f.Project {
+		return false
+	}
+	if f.Series != nil && (task.SeriesID == nil || *task.SeriesID != *f.Series) {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.matchTags(task) {
+		return false
+	}
+	return true
+}
+
+func (f TaskFilter) matchTags(task *entity.Task) bool {
+	for _, tag := range f.Tags {
+		found := slices.Contains(task.Tags, tag)
+		if f.AnyTag && found {
+			return true
+		}
+		if !f.AnyTag && !found {
+			return false
+		}
+	}
+	return !f.AnyTag
+}
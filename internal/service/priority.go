@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strings"
+	"webServerEx/internal/entity"
+)
+
+const (
+	PriorityP0   = "P0"
+	PriorityP1   = "P1"
+	PriorityP2   = "P2"
+	PriorityP3   = "P3"
+	PriorityNone = "none"
+)
+
+var priorityRanks = map[string]int{
+	PriorityP0: 0,
+	PriorityP1: 1,
+	PriorityP2: 2,
+	PriorityP3: 3,
+	"":         4,
+}
+
+func NormalizePriority(priority string) (string, error) {
+	priority = strings.ToUpper(strings.TrimSpace(priority))
+	if priority == strings.ToUpper(PriorityNone) {
+		priority = ""
+	}
+	if _, ok := priorityRanks[priority]; !ok {
+		return "", ErrInvalidPriority
+	}
+	return priority, nil
+}
+
+func priorityRank(task *entity.Task) int {
+	return priorityRanks[task.Priority]
+}
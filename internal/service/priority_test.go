@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestServicePriorities(t *testing.T) {
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+	service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithClock(func() time.Time { return now }))
+	ctx := testContext()
+	soon := now.Add(time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	inputs := []TaskInput{
+		{Title: "low", Priority: "p3"},
+		{Title: "urgent later", Priority: PriorityP0, DueAt: &later},
+		{Title: "none"},
+		{Title: "urgent soon", Priority: PriorityP0, DueAt: &soon},
+		{Title: "done", Priority: PriorityP0, Finished: true},
+	}
+	for _, input := range inputs {
+		if err := service.AddTask(ctx, input); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	t.Run("invalid priority", func(t *testing.T) {
+		if err := service.AddTask(ctx, TaskInput{Title: "bad", Priority: "P9"}); !errors.Is(err, ErrInvalidPriority) {
+			t.Errorf("expected ErrInvalidPriority, got %v", err)
+		}
+	})
+
+	t.Run("sort and filter", func(t *testing.T) {
+		tasks, err := service.GetAllTasks(ctx, TaskFilter{Sort: SortPriority})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expected := []string{"urgent soon", "urgent later", "done", "low", "none"}
+		for i, title := range expected {
+			if tasks[i].Title != title {
+				t.Errorf("position %d: expected %s, got %s", i, title, tasks[i].Title)
+			}
+		}
+		if tasks[3].Priority != PriorityP3 {
+			t.Errorf("priority must be normalized, got %s", tasks[3].Priority)
+		}
+
+		tasks, _ = service.GetAllTasks(ctx, TaskFilter{Sort: "-" + SortPriority})
+		if tasks[0].Title != "none" {
+			t.Errorf("expected none first in descending order, got %s", tasks[0].Title)
+		}
+		tasks, _ = service.GetAllTasks(ctx, TaskFilter{Priorities: []string{PriorityP3, ""}})
+		if len(tasks) != 2 {
+			t.Errorf("expected 2 tasks, got %d", len(tasks))
+		}
+	})
+
+	t.Run("next task", func(t *testing.T) {
+		task, err := service.NextTask(ctx, time.UTC)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if task.Title != "urgent soon" {
+			t.Errorf("uncorrect next task: %s", task.Title)
+		}
+	})
+
+	t.Run("no unfinished tasks", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(ctx, TaskInput{Title: "done", Finished: true})
+		if _, err := service.NextTask(ctx, time.UTC); !errors.Is(err, ErrNoTasks) {
+			t.Errorf("expected ErrNoTasks, got %v", err)
+		}
+	})
+
+	t.Run("empty storage", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		if _, err := service.NextTask(ctx, time.UTC); !errors.Is(err, ErrNoTasks) {
+			t.Errorf("expected ErrNoTasks, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/entity"
+)
+
+const maxProjectNameLength = 100
+
+var (
+	ErrInvalidProject      = errors.New("invalid project")
+	ErrProjectNotFound     = errors.New("project not found")
+	ErrProjectArchived     = errors.New("project is archived")
+	ErrInboxProject        = errors.New("inbox project cannot be changed")
+	ErrInvalidDeletePolicy = errors.New("invalid project delete policy")
+)
+
+type ProjectInput struct {
+	Name     string
+	Color    string
+	Archived bool
+	Position *int
+}
+
+func validateProject(input ProjectInput) (ProjectInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || utf8.RuneCountInString(input.Name) > maxProjectNameLength {
+		return input, ErrInvalidProject
+	}
+	if input.Color != "" {
+		color, err := NormalizeColor(input.Color)
+		if err != nil {
+			return input, err
+		}
+		input.Color = color
+	}
+	return input, nil
+}
+
+func NormalizeColor(color string) (string, error) {
+	color = strings.ToLower(color)
+	hex, found := strings.CutPrefix(color, "#")
+	if !found || len(hex) != 3 && len(hex) != 6 {
+		return "", ErrInvalidProject
+	}
+	for _, ch := range hex {
+		if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f') {
+			return "", ErrInvalidProject
+		}
+	}
+	return color, nil
+}
+
+func ValidDeletePolicy(policy string) bool {
+	switch policy {
+	case entity.ProjectDeleteCascade, entity.ProjectDeleteInbox, entity.ProjectDeleteRestrict:
+		return true
+	}
+	return false
+}
+
+func (r *tasksService) canAccessProject(ctx context.Context, project *entity.Project) bool {
+	return project.ID == entity.InboxProjectID || r.owns(ctx, project.OwnerID)
+}
+
+func (r *tasksService) ownedProject(ctx context.Context, repository Repository, id uint64) (*entity.Project, error) {
+	project, err := repository.GetProject(id)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil || !r.canAccessProject(ctx, project) {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+func (r *tasksService) assignableProject(ctx context.Context, repository Repository, id uint64) error {
+	project, err := r.ownedProject(ctx, repository, id)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+func parseProjectID(id string) (uint64, error) {
+	projectID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	return projectID, nil
+}
+
+func (r *tasksService) ListProjects(ctx context.Context, archived bool) ([]*entity.Project, error) {
+	if err := r.authorizer.Authorize(ctx, ActionProjectsRead); err != nil {
+		log.Printf("---Service: failed to list projects: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to list projects: %v", err)
+		return nil, err
+	}
+	projects, err := repository.GetProjects()
+	if err != nil {
+		log.Printf("---Service: failed to list projects from repository: %v", err)
+		return nil, err
+	}
+	visible := projects[:0:0]
+	for _, project := range projects {
+		if r.canAccessProject(ctx, project) && (archived || !project.Archived) {
+			visible = append(visible, project)
+		}
+	}
+	slices.SortFunc(visible, func(a, b *entity.Project) int {
+		return cmp.Or(cmp.Compare(a.Position, b.Position), cmp.Compare(a.ID, b.ID))
+	})
+	log.Println("---Service: projects listed successfully")
+	return visible, nil
+}
+
+func (r *tasksService) GetProject(ctx context.Context, id string) (*entity.Project, error) {
+	if err := r.authorizer.Authorize(ctx, ActionProjectsRead); err != nil {
+		log.Printf("---Service: failed to get project: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get project: %v", err)
+		return nil, err
+	}
+	projectID, err := parseProjectID(id)
+	if err != nil {
+		log.Printf("---Service: failed to get project: %v", err)
+		return nil, err
+	}
+	project, err := r.ownedProject(ctx, repository, projectID)
+	if err != nil {
+		log.Printf("---Service: failed to get project from repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: project got successfully")
+	return project, nil
+}
+
+func (r *tasksService) AddProject(ctx context.Context, input ProjectInput) (*entity.Project, error) {
+	if err := r.authorizer.Authorize(ctx, ActionProjectsWrite); err != nil {
+		log.Printf("---Service: failed to add project: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to add project: %v", err)
+		return nil, err
+	}
+	if input, err = validateProject(input); err != nil {
+		log.Printf("---Service: failed to add project: %v", err)
+		return nil, err
+	}
+	now := r.timestamp()
+	project := &entity.Project{
+		Name:      input.Name,
+		Color:     input.Color,
+		Archived:  input.Archived,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if input.Position != nil {
+		project.Position = *input.Position
+	} else if project.Position, err = r.nextPosition(ctx, repository); err != nil {
+		log.Printf("---Service: failed to add project: %v", err)
+		return nil, err
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		project.OwnerID = principal.Subject()
+	}
+	if err = repository.AddProject(project); err != nil {
+		log.Printf("---Service: failed to add project to repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: project added successfully")
+	return project, nil
+}
+
+func (r *tasksService) nextPosition(ctx context.Context, repository Repository) (int, error) {
+	projects, err := repository.GetProjects()
+	if err != nil {
+		return 0, err
+	}
+	position := 0
+	for _, project := range projects {
+		if r.canAccessProject(ctx, project) && project.Position >= position {
+			position = project.Position + 1
+		}
+	}
+	return position, nil
+}
+
+func (r *tasksService) UpdateProject(ctx context.Context, id string, input ProjectInput) error {
+	if err := r.authorizer.Authorize(ctx, ActionProjectsWrite); err != nil {
+		log.Printf("---Service: failed to update project: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to update project: %v", err)
+		return err
+	}
+	projectID, err := parseProjectID(id)
+	if err != nil {
+		log.Printf("---Service: failed to update project: %v", err)
+		return err
+	}
+	if projectID == entity.InboxProjectID {
+		log.Printf("---Service: failed to update project: %v", ErrInboxProject)
+		return ErrInboxProject
+	}
+	if input, err = validateProject(input); err != nil {
+		log.Printf("---Service: failed to update project: %v", err)
+		return err
+	}
+	current, err := r.ownedProject(ctx, repository, projectID)
+	if err != nil {
+		log.Printf("---Service: failed to update project: %v", err)
+		return err
+	}
+	project := &entity.Project{
+		Name:      input.Name,
+		Color:     input.Color,
+		Archived:  input.Archived,
+		Position:  current.Position,
+		OwnerID:   current.OwnerID,
+		CreatedAt: current.CreatedAt,
+		UpdatedAt: r.timestamp(),
+	}
+	if input.Position != nil {
+		project.Position = *input.Position
+	}
+	if err = repository.UpdateProject(projectID, project); err != nil {
+		log.Printf("---Service: failed to update project in repository: %v", err)
+		return err
+	}
+	log.Println("---Service: project updated successfully")
+	return nil
+}
+
+func (r *tasksService) DeleteProject(ctx context.Context, id string, policy string) (int, error) {
+	if err := r.authorizer.Authorize(ctx, ActionProjectsWrite); err != nil {
+		log.Printf("---Service: failed to delete project: %v", err)
+		return 0, err
+	}
+	if policy == "" {
+		policy = entity.ProjectDeleteRestrict
+	}
+	if !ValidDeletePolicy(policy) {
+		log.Printf("---Service: failed to delete project: %v", ErrInvalidDeletePolicy)
+		return 0, ErrInvalidDeletePolicy
+	}
+	if policy == entity.ProjectDeleteCascade {
+		if err := r.authorizer.Authorize(ctx, ActionTasksDelete); err != nil {
+			log.Printf("---Service: failed to delete project: %v", err)
+			return 0, err
+		}
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to delete project: %v", err)
+		return 0, err
+	}
+	projectID, err := parseProjectID(id)
+	if err != nil {
+		log.Printf("---Service: failed to delete project: %v", err)
+		return 0, err
+	}
+	if projectID == entity.InboxProjectID {
+		log.Printf("---Service: failed to delete project: %v", ErrInboxProject)
+		return 0, ErrInboxProject
+	}
+	if _, err = r.ownedProject(ctx, repository, projectID); err != nil {
+		log.Printf("---Service: failed to delete project: %v", err)
+		return 0, err
+	}
+	affected, err := repository.DeleteProject(projectID, policy, r.timestamp())
+	if err != nil {
+		log.Printf("---Service: failed to delete project from repository: %v", err)
+		return 0, err
+	}
+	log.Println("---Service: project deleted successfully")
+	return affected, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+	"webServerEx/internal/entity"
+)
+
+func TestServiceProjects(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	bob := principalContext("bob", auth.ScopeTasksWrite)
+	viewer := principalContext("viewer", auth.ScopeTasksRead)
+	newService := func() Service {
+		return NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+	}
+
+	t.Run("inbox is default project", func(t *testing.T) {
+		service := newService()
+		if err := service.AddTask(alice, TaskInput{Title: "test"}); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "0")
+		if task.ProjectID != entity.InboxProjectID {
+			t.Errorf("uncorrect project id: %d", task.ProjectID)
+		}
+		projects, _ := service.ListProjects(bob, false)
+		if len(projects) != 1 || projects[0].Name != "Inbox" {
+			t.Errorf("uncorrect projects: %v", projects)
+		}
+	})
+
+	t.Run("projects are ordered and owned", func(t *testing.T) {
+		service := newService()
+		work, err := service.AddProject(alice, ProjectInput{Name: " Work ", Color: "#FFAA00"})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if work.Name != "Work" || work.Color != "#ffaa00" || work.Position != 1 {
+			t.Errorf("uncorrect project: %+v", work)
+		}
+		first := -1
+		service.AddProject(alice, ProjectInput{Name: "Home", Position: &first})
+		service.AddProject(alice, ProjectInput{Name: "Old", Archived: true})
+		projects, _ := service.ListProjects(alice, false)
+		if len(projects) != 3 || projects[0].Name != "Home" || projects[2].Name != "Work" {
+			t.Errorf("uncorrect projects order: %v", projects)
+		}
+		if projects, _ = service.ListProjects(alice, true); len(projects) != 4 {
+			t.Errorf("expected 4 projects with archived, got %d", len(projects))
+		}
+		if _, err := service.GetProject(bob, "1"); !errors.Is(err, ErrProjectNotFound) {
+			t.Errorf("expected ErrProjectNotFound, got %v", err)
+		}
+		if _, err := service.AddProject(viewer, ProjectInput{Name: "Work"}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		if _, err := service.AddProject(alice, ProjectInput{Name: "Work", Color: "red"}); !errors.Is(err, ErrInvalidProject) {
+			t.Errorf("expected ErrInvalidProject, got %v", err)
+		}
+		if err := service.UpdateProject(alice, "0", ProjectInput{Name: "Main"}); !errors.Is(err, ErrInboxProject) {
+			t.Errorf("expected ErrInboxProject, got %v", err)
+		}
+	})
+
+	t.Run("move tasks between projects", func(t *testing.T) {
+		service := newService()
+		work, _ := service.AddProject(alice, ProjectInput{Name: "Work"})
+		archived, _ := service.AddProject(alice, ProjectInput{Name: "Old", Archived: true})
+		service.AddTask(alice, TaskInput{Title: "report", ProjectID: &work.ID})
+		if err := service.AddTask(alice, TaskInput{Title: "test", ProjectID: &archived.ID}); !errors.Is(err, ErrProjectArchived) {
+			t.Errorf("expected ErrProjectArchived, got %v", err)
+		}
+		if err := service.AddTask(bob, TaskInput{Title: "test", ProjectID: &work.ID}); !errors.Is(err, ErrProjectNotFound) {
+			t.Errorf("expected ErrProjectNotFound, got %v", err)
+		}
+		inbox := entity.InboxProjectID
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "report", ProjectID: &inbox}); err != nil {
+			t.Fatal(err.Error())
+		}
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{Project: &work.ID})
+		if len(tasks) != 0 {
+			t.Errorf("expected empty project, got %d tasks", len(tasks))
+		}
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "renamed"}); err != nil {
+			t.Fatal(err.Error())
+		}
+		tasks, _ = service.GetAllTasks(alice, TaskFilter{Project: &inbox})
+		if len(tasks) != 1 || tasks[0].Title != "renamed" {
+			t.Errorf("expected task to stay in inbox, got %v", tasks)
+		}
+	})
+
+	t.Run("delete policies", func(t *testing.T) {
+		service := newService()
+		work, _ := service.AddProject(alice, ProjectInput{Name: "Work"})
+		home, _ := service.AddProject(alice, ProjectInput{Name: "Home"})
+		service.AddTask(alice, TaskInput{Title: "report", ProjectID: &work.ID})
+		service.AddTask(alice, TaskInput{Title: "groceries", ProjectID: &home.ID})
+
+		if _, err := service.DeleteProject(alice, "1", ""); !errors.Is(err, inmemory.ErrProjectNotEmpty) {
+			t.Errorf("expected ErrProjectNotEmpty, got %v", err)
+		}
+		if _, err := service.DeleteProject(alice, "1", "archive"); !errors.Is(err, ErrInvalidDeletePolicy) {
+			t.Errorf("expected ErrInvalidDeletePolicy, got %v", err)
+		}
+		if _, err := service.DeleteProject(alice, "0", entity.ProjectDeleteCascade); !errors.Is(err, ErrInboxProject) {
+			t.Errorf("expected ErrInboxProject, got %v", err)
+		}
+		moved, err := service.DeleteProject(alice, "1", entity.ProjectDeleteInbox)
+		if err != nil || moved != 1 {
+			t.Fatalf("uncorrect move to inbox: %d %v", moved, err)
+		}
+		task, _ := service.GetTask(alice, "0")
+		if task.ProjectID != entity.InboxProjectID {
+			t.Errorf("expected task in inbox, got project %d", task.ProjectID)
+		}
+		deleted, err := service.DeleteProject(alice, "2", entity.ProjectDeleteCascade)
+		if err != nil || deleted != 1 {
+			t.Fatalf("uncorrect cascade: %d %v", deleted, err)
+		}
+		if _, err := service.GetTask(alice, "1"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+const (
+	maxRecurrenceInterval = 1000
+	maxSkippedOccurrences = 100000
+	lastMonthDay          = -1
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+func weekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func recurrenceLocation(rule *entity.Recurrence, allDay bool) *time.Location {
+	if allDay {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(rule.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func NormalizeRecurrence(rule *entity.Recurrence, dueAt *time.Time, allDay bool) (*entity.Recurrence, error) {
+	if rule == nil {
+		return nil, nil
+	}
+	if dueAt == nil {
+		return nil, ErrInvalidRecurrence
+	}
+	normalized := *rule
+	normalized.Frequency = strings.ToLower(strings.TrimSpace(rule.Frequency))
+	switch normalized.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return nil, ErrInvalidRecurrence
+	}
+	if normalized.Interval == 0 {
+		normalized.Interval = 1
+	}
+	if normalized.Interval < 0 || normalized.Interval > maxRecurrenceInterval || normalized.Count < 0 {
+		return nil, ErrInvalidRecurrence
+	}
+	if normalized.Until != nil && normalized.Until.Before(*dueAt) {
+		return nil, ErrInvalidRecurrence
+	}
+	if normalized.Timezone == "" {
+		normalized.Timezone = time.UTC.String()
+	}
+	if _, err := time.LoadLocation(normalized.Timezone); err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+	if len(rule.Weekdays) > 0 && normalized.Frequency != FrequencyWeekly {
+		return nil, ErrInvalidRecurrence
+	}
+	normalized.Weekdays = nil
+	for _, day := range rule.Weekdays {
+		day = strings.ToUpper(strings.TrimSpace(day))
+		if _, ok := weekdays[day]; !ok {
+			return nil, ErrInvalidRecurrence
+		}
+		normalized.Weekdays = append(normalized.Weekdays, day)
+	}
+	slices.SortFunc(normalized.Weekdays, func(a, b string) int {
+		return weekdayIndex(weekdays[a]) - weekdayIndex(weekdays[b])
+	})
+	normalized.Weekdays = slices.Compact(normalized.Weekdays)
+	switch {
+	case normalized.MonthDay != 0 && normalized.Frequency != FrequencyMonthly:
+		return nil, ErrInvalidRecurrence
+	case normalized.MonthDay == 0 && normalized.Frequency == FrequencyMonthly:
+		normalized.MonthDay = dueAt.In(recurrenceLocation(&normalized, allDay)).Day()
+	case normalized.MonthDay < lastMonthDay || normalized.MonthDay > 31:
+		return nil, ErrInvalidRecurrence
+	}
+	return &normalized, nil
+}
+
+func nextOccurrence(rule *entity.Recurrence, due time.Time, allDay bool) time.Time {
+	local := due.In(recurrenceLocation(rule, allDay))
+	y, m, d := local.Date()
+	hour, minute, second := local.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, second, local.Nanosecond(), local.Location())
+	}
+	switch rule.Frequency {
+	case FrequencyWeekly:
+		if len(rule.Weekdays) == 0 {
+			return at(y, m, d+7*rule.Interval)
+		}
+		monday := weekdayIndex(local.Weekday())
+		for offset := 1; offset <= 7*rule.Interval+7; offset++ {
+			day := at(y, m, d+offset)
+			week := (monday + offset) / 7
+			if week%rule.Interval == 0 && slices.ContainsFunc(rule.Weekdays, func(name string) bool {
+				return weekdays[name] == day.Weekday()
+			}) {
+				return day
+			}
+		}
+		return at(y, m, d+7*rule.Interval)
+	case FrequencyMonthly:
+		month := time.Date(y, m+time.Month(rule.Interval), 1, 0, 0, 0, 0, time.UTC)
+		last := month.AddDate(0, 1, -1).Day()
+		day := rule.MonthDay
+		if day == lastMonthDay || day > last {
+			day = last
+		}
+		return at(month.Year(), month.Month(), day)
+	}
+	return at(y, m, d+rule.Interval)
+}
+
+func nextDue(rule *entity.Recurrence, due time.Time, allDay bool, now time.Time) (time.Time, bool) {
+	if rule.Count == 1 {
+		return time.Time{}, false
+	}
+	today := startOfDay(now, recurrenceLocation(rule, false))
+	next := due
+	for range maxSkippedOccurrences {
+		next = nextOccurrence(rule, next, allDay)
+		if rule.Until != nil && next.After(*rule.Until) {
+			return time.Time{}, false
+		}
+		if allDay {
+			y, m, d := next.Date()
+			if !time.Date(y, m, d, 0, 0, 0, 0, today.Location()).Before(today) {
+				return next, true
+			}
+		} else if next.After(now) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func nextInstance(task *entity.Task, now time.Time) *entity.Task {
+	if task.Recurrence == nil || task.DueAt == nil {
+		return nil
+	}
+	due, ok := nextDue(task.Recurrence, *task.DueAt, task.DueAllDay, now)
+	if !ok {
+		return nil
+	}
+	rule := *task.Recurrence
+	if rule.Count > 0 {
+		rule.Count--
+	}
+	seriesID := task.ID
+	if task.SeriesID != nil {
+		seriesID = *task.SeriesID
+	}
+	return &entity.Task{
+		Title:       task.Title,
+		Description: task.Description,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		OwnerID:     task.OwnerID,
+		CreatedBy:   task.CreatedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		DueAt:       &due,
+		DueAllDay:   task.DueAllDay,
+		Priority:    task.Priority,
+		Tags:        task.Tags,
+		Recurrence:  &rule,
+		SeriesID:    &seriesID,
+	}
+}
+
+func (r *tasksService) rollover(task *entity.Task, now time.Time) *entity.Task {
+	if task.Recurrence == nil {
+		return nil
+	}
+	next := nextInstance(task, now)
+	if next != nil {
+		next.Status = r.workflow.initial
+	}
+	task.Recurrence = nil
+	if task.SeriesID == nil {
+		seriesID := task.ID
+		task.SeriesID = &seriesID
+	}
+	return next
+}
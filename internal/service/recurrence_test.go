@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+	"webServerEx/internal/entity"
+)
+
+func TestNormalizeRecurrence(t *testing.T) {
+	due := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	tableTests := []struct {
+		name string
+		rule entity.Recurrence
+		due  *time.Time
+	}{
+		{name: "without due date", rule: entity.Recurrence{Frequency: "daily"}},
+		{name: "unknown frequency", rule: entity.Recurrence{Frequency: "hourly"}, due: &due},
+		{name: "unknown weekday", rule: entity.Recurrence{Frequency: "weekly", Weekdays: []string{"XX"}}, due: &due},
+		{name: "weekdays on daily", rule: entity.Recurrence{Frequency: "daily", Weekdays: []string{"MO"}}, due: &due},
+		{name: "month day out of range", rule: entity.Recurrence{Frequency: "monthly", MonthDay: 32}, due: &due},
+		{name: "unknown timezone", rule: entity.Recurrence{Frequency: "daily", Timezone: "Mars/Base"}, due: &due},
+		{name: "negative count", rule: entity.Recurrence{Frequency: "daily", Count: -1}, due: &due},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeRecurrence(&tt.rule, tt.due, false); !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("expected ErrInvalidRecurrence, got %v", err)
+			}
+		})
+	}
+
+	rule, err := NormalizeRecurrence(&entity.Recurrence{Frequency: " Monthly "}, &due, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rule.Frequency != FrequencyMonthly || rule.Interval != 1 || rule.MonthDay != 31 || rule.Timezone != "UTC" {
+		t.Errorf("uncorrect rule: %+v", rule)
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tableTests := []struct {
+		name     string
+		rule     entity.Recurrence
+		due      time.Time
+		allDay   bool
+		expected time.Time
+	}{
+		{
+			name:     "daily keeps wall clock across DST",
+			rule:     entity.Recurrence{Frequency: FrequencyDaily, Interval: 1, Timezone: "America/New_York"},
+			due:      time.Date(2024, 3, 9, 9, 0, 0, 0, newYork),
+			expected: time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+		},
+		{
+			name:     "every second week on monday and wednesday",
+			rule:     entity.Recurrence{Frequency: FrequencyWeekly, Interval: 2, Weekdays: []string{"MO", "WE"}, Timezone: "UTC"},
+			due:      time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly on 31st clamps to february",
+			rule:     entity.Recurrence{Frequency: FrequencyMonthly, Interval: 1, MonthDay: 31, Timezone: "UTC"},
+			due:      time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			allDay:   true,
+			expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "monthly on last day",
+			rule:     entity.Recurrence{Frequency: FrequencyMonthly, Interval: 1, MonthDay: -1, Timezone: "UTC"},
+			due:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			allDay:   true,
+			expected: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := nextOccurrence(&tt.rule, tt.due, tt.allDay)
+			if !next.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestServiceRecurrence(t *testing.T) {
+	now := time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC)
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	newService := func() Service {
+		return NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithClock(func() time.Time { return now }))
+	}
+	due := time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("finishing creates next occurrence", func(t *testing.T) {
+		service := newService()
+		rule := &entity.Recurrence{Frequency: FrequencyDaily, Count: 2}
+		service.AddTask(alice, TaskInput{Title: "standup", DueAt: &due, DueAllDay: true, Recurrence: rule})
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "standup", Finished: true, DueAt: &due, DueAllDay: true, Recurrence: rule}); err != nil {
+			t.Fatal(err.Error())
+		}
+		completed, _ := service.GetTask(alice, "0")
+		if !completed.Finished || completed.Recurrence != nil || completed.SeriesID == nil || *completed.SeriesID != 0 {
+			t.Errorf("uncorrect completed instance: %+v", completed)
+		}
+		next, err := service.GetTask(alice, "1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if next.Finished || !next.DueAt.Equal(due.AddDate(0, 0, 1)) || next.Recurrence.Count != 1 || *next.SeriesID != 0 {
+			t.Errorf("uncorrect next instance: %+v", next)
+		}
+		if err := service.UpdateTask(alice, "1", TaskInput{Title: "standup", Finished: true, DueAt: next.DueAt, DueAllDay: true, Recurrence: next.Recurrence}); err != nil {
+			t.Fatal(err.Error())
+		}
+		series := uint64(0)
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{Series: &series})
+		if len(tasks) != 2 {
+			t.Errorf("expected exhausted series of 2 tasks, got %d", len(tasks))
+		}
+	})
+
+	t.Run("finishing without recurrence keeps series", func(t *testing.T) {
+		service := newService()
+		rule := &entity.Recurrence{Frequency: FrequencyDaily}
+		service.AddTask(alice, TaskInput{Title: "standup", DueAt: &due, DueAllDay: true, Recurrence: rule})
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "standup", Finished: true, DueAt: &due, DueAllDay: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		next, err := service.GetTask(alice, "1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if next.Recurrence == nil || !next.DueAt.Equal(due.AddDate(0, 0, 1)) {
+			t.Errorf("uncorrect next instance: %+v", next)
+		}
+	})
+
+	t.Run("clear recurrence", func(t *testing.T) {
+		service := newService()
+		rule := &entity.Recurrence{Frequency: FrequencyDaily}
+		service.AddTask(alice, TaskInput{Title: "standup", DueAt: &due, DueAllDay: true, Recurrence: rule})
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "standup", DueAt: &due, DueAllDay: true, ClearRecurrence: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		if task, _ := service.GetTask(alice, "0"); task.Recurrence != nil {
+			t.Errorf("expected cleared recurrence, got %+v", task.Recurrence)
+		}
+		service.UpdateTask(alice, "0", TaskInput{Title: "standup", Finished: true, DueAt: &due, DueAllDay: true})
+		if _, err := service.GetTask(alice, "1"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("title only update keeps recurrence", func(t *testing.T) {
+		service := newService()
+		rule := &entity.Recurrence{Frequency: FrequencyDaily}
+		service.AddTask(alice, TaskInput{Title: "standup", DueAt: &due, DueAllDay: true, Recurrence: rule})
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "daily standup"}); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "0")
+		if task.Title != "daily standup" || task.Recurrence == nil || task.DueAt == nil || !task.DueAt.Equal(due) || !task.DueAllDay {
+			t.Errorf("uncorrect updated task: %+v", task)
+		}
+	})
+
+	t.Run("overdue series skips to future", func(t *testing.T) {
+		service := newService()
+		past := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+		rule := &entity.Recurrence{Frequency: FrequencyDaily}
+		service.AddTask(alice, TaskInput{Title: "water plants", DueAt: &past, Recurrence: rule})
+		service.UpdateTask(alice, "0", TaskInput{Title: "water plants", Finished: true, DueAt: &past, Recurrence: rule})
+		next, err := service.GetTask(alice, "1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !next.DueAt.Equal(time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC)) {
+			t.Errorf("uncorrect next due: %v", next.DueAt)
+		}
+	})
+
+	t.Run("until ends series", func(t *testing.T) {
+		service := newService()
+		until := due.Add(time.Hour)
+		rule := &entity.Recurrence{Frequency: FrequencyDaily, Until: &until}
+		service.AddTask(alice, TaskInput{Title: "once", DueAt: &due, DueAllDay: true, Recurrence: rule})
+		service.UpdateTask(alice, "0", TaskInput{Title: "once", Finished: true, DueAt: &due, DueAllDay: true, Recurrence: rule})
+		if _, err := service.GetTask(alice, "1"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+}
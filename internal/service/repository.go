@@ -1,6 +1,9 @@
 package service
 
-import "webServerEx/internal/entity"
+import (
+	"time"
+	"webServerEx/internal/entity"
+)
 
 type Repository interface {
 	Add(task *entity.Task) error
@@ -9,6 +12,31 @@ type Repository interface {
 	Get(id uint64) (*entity.Task, error)
 	GetAll() ([]*entity.Task, error)
 	Update(id uint64, task *entity.Task) error
+	FindByTags(tags []string, matchAll bool) ([]*entity.Task, error)
+	TagCounts() (map[string]int, error)
+	RenameTag(from, to string, updatedAt time.Time) (int, error)
+	AddProject(project *entity.Project) error
+	GetProject(id uint64) (*entity.Project, error)
+	GetProjects() ([]*entity.Project, error)
+	UpdateProject(id uint64, project *entity.Project) error
+	DeleteProject(id uint64, policy string, updatedAt time.Time) (int, error)
+	FindByProject(id uint64) ([]*entity.Task, error)
+	Children(id uint64) ([]*entity.Task, error)
+	Descendants(id uint64) ([]*entity.Task, error)
+	MoveSubtree(id uint64, parentID *uint64, projectID uint64, updatedAt time.Time) error
+	UpdateSubtree(id uint64, task *entity.Task, finished, added []*entity.Task) error
+	AddDependency(id, blockerID uint64, updatedAt time.Time) error
+	RemoveDependency(id, blockerID uint64, updatedAt time.Time) error
+	Dependents(id uint64) ([]*entity.Task, error)
+	CommentRepository
+}
+
+type CommentRepository interface {
+	AddComment(comment *entity.Comment) error
+	GetComment(id uint64) (*entity.Comment, error)
+	ListComments(taskID uint64, offset, limit int) ([]*entity.Comment, int, error)
+	UpdateComment(id uint64, comment *entity.Comment) error
+	DeleteComment(id uint64) error
 }
 
 type tasksRepository struct {
@@ -38,3 +66,82 @@ func (r *tasksRepository) GetAll() ([]*entity.Task, error) {
 func (r *tasksRepository) Update(id uint64, task *entity.Task) error {
 	return r.storage.Update(id, task)
 }
+func (r *tasksRepository) FindByTags(tags []string, matchAll bool) ([]*entity.Task, error) {
+	return r.storage.FindByTags(tags, matchAll)
+}
+func (r *tasksRepository) TagCounts() (map[string]int, error) {
+	return r.storage.TagCounts()
+}
+func (r *tasksRepository) RenameTag(from, to string, updatedAt time.Time) (int, error) {
+	return r.storage.RenameTag(from, to, updatedAt)
+}
+func (r *tasksRepository) AddProject(project *entity.Project) error {
+	return r.storage.AddProject(project)
+}
+func (r *tasksRepository) GetProject(id uint64) (*entity.Project, error) {
+	return r.storage.GetProject(id)
+}
+func (r *tasksRepository) GetProjects() ([]*entity.Project, error) {
+	return r.storage.GetProjects()
+}
+func (r *tasksRepository) UpdateProject(id uint64, project *entity.Project) error {
+	return r.storage.UpdateProject(id, project)
+}
+func (r *tasksRepository) DeleteProject(id uint64, policy string, updatedAt time.Time) (int, error) {
+	return r.storage.DeleteProject(id, policy, updatedAt)
+}
+func (r *tasksRepository) FindByProject(id uint64) ([]*entity.Task, error) {
+	return r.storage.FindByProject(id)
+}
+func (r *tasksRepository) Children(id uint64) ([]*entity.Task, error) {
+	return r.storage.Children(id)
+}
+func (r *tasksRepository) Descendants(id uint64) ([]*entity.Task, error) {
+	return r.storage.Descendants(id)
+}
+func (r *tasksRepository) MoveSubtree(id uint64, parentID *uint64, projectID uint64, updatedAt time.Time) error {
+	return r.storage.MoveSubtree(id, parentID, projectID, updatedAt)
+}
+func (r *tasksRepository) UpdateSubtree(id uint64, task *entity.Task, finished, added []*entity.Task) error {
+	return r.storage.UpdateSubtree(id, task, finished, added)
+}
+func (r *tasksRepository) AddDependency(id, blockerID uint64, updatedAt time.Time) error {
+	return r.storage.AddDependency(id, blockerID, updatedAt)
+}
+func (r *tasksRepository) RemoveDependency(id, blockerID uint64, updatedAt time.Time) error {
+	return r.storage.RemoveDependency(id, blockerID, updatedAt)
+}
+func (r *tasksRepository) Dependents(id uint64) ([]*entity.Task, error) {
+	return r.storage.Dependents(id)
+}
+func (r *tasksRepository) AddComment(comment *entity.Comment) error {
+	return r.storage.AddComment(comment)
+}
+func (r *tasksRepository) GetComment(id uint64) (*entity.Comment, error) {
+	return r.storage.GetComment(id)
+}
+func (r *tasksRepository) ListComments(taskID uint64, offset, limit int) ([]*entity.Comment, int, error) {
+	return r.storage.ListComments(taskID, offset, limit)
+}
+func (r *tasksRepository) UpdateComment(id uint64, comment *entity.Comment) error {
+	return r.storage.UpdateComment(id, comment)
+}
+func (r *tasksRepository) DeleteComment(id uint64) error {
+	return r.storage.DeleteComment(id)
+}
+
+type TenantRepositories interface {
+	ForTenant(tenantID string) (Repository, error)
+}
+
+type TenantRepositoriesFunc func(tenantID string) (Repository, error)
+
+func (f TenantRepositoriesFunc) ForTenant(tenantID string) (Repository, error) {
+	return f(tenantID)
+}
+
+func SingleTenant(repository Repository) TenantRepositories {
+	return TenantRepositoriesFunc(func(string) (Repository, error) {
+		return repository, nil
+	})
+}
@@ -1,44 +1,197 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"log"
+	"slices"
 	"strconv"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
 	"webServerEx/internal/entity"
+	"webServerEx/internal/tenant"
 )
 
 var (
-	ErrInvalidTitle = errors.New("invalid title")
-	ErrInvalidID    = errors.New("invalid input id")
+	ErrInvalidTitle    = errors.New("invalid title")
+	ErrInvalidID       = errors.New("invalid input id")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrInvalidDue      = errors.New("invalid due date")
+	ErrInvalidPriority = errors.New("invalid priority")
+	ErrNoTasks         = errors.New("no unfinished tasks")
 )
 
+type TaskInput struct {
+	Title           string
+	Description     string
+	Finished        bool
+	Status          string
+	DueAt           *time.Time
+	DueAllDay       bool
+	Priority        string
+	Tags            []string
+	ProjectID       *uint64
+	ParentID        *uint64
+	Recurrence      *entity.Recurrence
+	ClearRecurrence bool
+	Force           bool
+}
+
 type Service interface {
-	AddTask(title, description string) error
-	UpdateTask(id, title, description string, finished bool) error
-	GetTask(id string) (*entity.Task, error)
-	GetAllTasks() ([]*entity.Task, error)
-	DeleteTask(id string) error
-	DeleteAllTasks() error
+	AddTask(ctx context.Context, input TaskInput) error
+	UpdateTask(ctx context.Context, id string, input TaskInput) error
+	GetTask(ctx context.Context, id string) (*entity.Task, error)
+	GetAllTasks(ctx context.Context, filter TaskFilter) ([]*entity.Task, error)
+	DeleteTask(ctx context.Context, id string) error
+	DeleteAllTasks(ctx context.Context) error
+	GetAgenda(ctx context.Context, loc *time.Location) (*Agenda, error)
+	NextTask(ctx context.Context, loc *time.Location) (*entity.Task, error)
+	ListTags(ctx context.Context) ([]TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+	ListProjects(ctx context.Context, archived bool) ([]*entity.Project, error)
+	GetProject(ctx context.Context, id string) (*entity.Project, error)
+	AddProject(ctx context.Context, input ProjectInput) (*entity.Project, error)
+	UpdateProject(ctx context.Context, id string, input ProjectInput) error
+	DeleteProject(ctx context.Context, id string, policy string) (int, error)
+	GetChildren(ctx context.Context, id string) ([]*entity.Task, error)
+	GetSubtree(ctx context.Context, id string) (*TaskTree, error)
+	MoveTask(ctx context.Context, id string, parentID *uint64) error
+	GetDependencies(ctx context.Context, id string) (*Dependencies, error)
+	AddDependency(ctx context.Context, id, blockerID string) error
+	RemoveDependency(ctx context.Context, id, blockerID string) error
+	PlanTasks(ctx context.Context, filter TaskFilter) ([]*entity.Task, error)
+	TransitionTask(ctx context.Context, id, status string, force bool) (*entity.Task, error)
+	StatusCounts(ctx context.Context, filter TaskFilter) (map[string]int, error)
+	ListComments(ctx context.Context, taskID string, offset, limit int) (*CommentsPage, error)
+	AddComment(ctx context.Context, taskID, body string) (*entity.Comment, error)
+	UpdateComment(ctx context.Context, taskID, id, body string) (*entity.Comment, error)
+	DeleteComment(ctx context.Context, taskID, id string) error
 }
 
 type tasksService struct {
-	repository Repository
+	repositories TenantRepositories
+	authorizer   *Authorizer
+	workflow     *Workflow
+	now          func() time.Time
+}
+
+type Option func(*tasksService)
+
+func WithClock(now func() time.Time) Option {
+	return func(s *tasksService) {
+		s.now = now
+	}
+}
+
+func WithWorkflow(workflow *Workflow) Option {
+	return func(s *tasksService) {
+		s.workflow = workflow
+	}
 }
 
-func NewTasksService(repository Repository) Service {
-	return &tasksService{repository: repository}
+func NewTasksService(repositories TenantRepositories, authorizer *Authorizer, opts ...Option) Service {
+	s := &tasksService{repositories: repositories, authorizer: authorizer, workflow: DefaultWorkflow(), now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (r *tasksService) timestamp() time.Time {
+	return r.now().UTC().Truncate(time.Second)
 }
 
-func (r *tasksService) AddTask(title, description string) error {
-	if title == "" {
-		log.Printf("---Service: failed to add task: %v", ErrInvalidTitle)
-		return ErrInvalidTitle
+func (r *tasksService) repository(ctx context.Context) (Repository, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.repositories.ForTenant(tenantID)
+}
+
+func (r *tasksService) canAccess(ctx context.Context, task *entity.Task) bool {
+	return r.owns(ctx, task.OwnerID)
+}
+
+func (r *tasksService) owns(ctx context.Context, ownerID string) bool {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || r.authorizer.Allowed(principal, ActionTasksManageAll) {
+		return true
+	}
+	return ownerID == principal.Subject()
+}
+
+func (r *tasksService) ownedTask(ctx context.Context, repository Repository, id uint64) (*entity.Task, error) {
+	task, err := repository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil || !r.canAccess(ctx, task) {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (r *tasksService) AddTask(ctx context.Context, input TaskInput) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksCreate); err != nil {
+		log.Printf("---Service: failed to add task: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to add task: %v", err)
+		return err
+	}
+	if input, err = validateInput(input); err != nil {
+		log.Printf("---Service: failed to add task: %v", err)
+		return err
 	}
+	status, err := r.workflow.initialStatus(input)
+	if err != nil {
+		log.Printf("---Service: failed to add task: %v", err)
+		return err
+	}
+	projectID := entity.InboxProjectID
+	if input.ProjectID != nil {
+		projectID = *input.ProjectID
+	}
+	if input.ParentID != nil {
+		if projectID, err = r.parentProject(ctx, repository, *input.ParentID, input.ProjectID); err != nil {
+			log.Printf("---Service: failed to add task: %v", err)
+			return err
+		}
+	}
+	if input.ProjectID != nil || input.ParentID != nil {
+		if err = r.assignableProject(ctx, repository, projectID); err != nil {
+			log.Printf("---Service: failed to add task: %v", err)
+			return err
+		}
+	}
+	now := r.timestamp()
 	task := &entity.Task{
-		Title:       title,
-		Description: description,
+		Title:       input.Title,
+		Description: input.Description,
+		Finished:    r.workflow.Terminal(status),
+		Status:      status,
+		ProjectID:   projectID,
+		ParentID:    input.ParentID,
+		Recurrence:  input.Recurrence,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		DueAt:       input.DueAt,
+		DueAllDay:   input.DueAllDay,
+		Priority:    input.Priority,
+		Tags:        input.Tags,
+	}
+	if task.Finished {
+		task.CompletedAt = &now
 	}
-	if err := r.repository.Add(task); err != nil {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		task.OwnerID = principal.Subject()
+		task.CreatedBy = principal.Subject()
+	}
+	if err = repository.Add(task); err != nil {
 		log.Printf("---Service: failed to add task to repository: %v", err)
 		return err
 	}
@@ -46,70 +199,345 @@ func (r *tasksService) AddTask(title, description string) error {
 	return nil
 }
 
-func (r *tasksService) UpdateTask(id, title, description string, finished bool) error {
+func (r *tasksService) UpdateTask(ctx context.Context, id string, input TaskInput) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksUpdate); err != nil {
+		log.Printf("---Service: failed to update task: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to update task: %v", err)
+		return err
+	}
 	correctID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
 		log.Printf("---Service: failed to update task: %v", ErrInvalidID)
 		return ErrInvalidID
 	}
-	if title == "" {
-		log.Printf("---Service: failed to update task: %v", ErrInvalidTitle)
-		return ErrInvalidTitle
+	if input, err = validateInput(input); err != nil {
+		log.Printf("---Service: failed to update task: %v", err)
+		return err
+	}
+	current, err := r.ownedTask(ctx, repository, correctID)
+	if err != nil {
+		log.Printf("---Service: failed to update task: %v", err)
+		return err
+	}
+	if input.Recurrence == nil && !input.ClearRecurrence && current.Recurrence != nil {
+		if input.DueAt == nil {
+			input.DueAt, input.DueAllDay = current.DueAt, current.DueAllDay
+		}
+		if input.Recurrence, err = NormalizeRecurrence(current.Recurrence, input.DueAt, input.DueAllDay); err != nil {
+			log.Printf("---Service: failed to update task: %v", err)
+			return err
+		}
 	}
+	status, err := r.workflow.nextStatus(current, input)
+	if err != nil {
+		log.Printf("---Service: failed to update task: %v", err)
+		return err
+	}
+	finished := r.workflow.Terminal(status)
+	finishing := finished && !r.workflow.Terminal(r.workflow.statusOf(current))
+	projectID, parentID := current.ProjectID, current.ParentID
+	switch {
+	case input.ParentID != nil && (current.ParentID == nil || *input.ParentID != *current.ParentID):
+		parentID = input.ParentID
+		if projectID, err = r.parentProject(ctx, repository, *input.ParentID, input.ProjectID); err != nil {
+			log.Printf("---Service: failed to update task: %v", err)
+			return err
+		}
+	case input.ProjectID != nil && *input.ProjectID != current.ProjectID:
+		if current.ParentID != nil {
+			log.Printf("---Service: failed to update task: %v", ErrInvalidParent)
+			return ErrInvalidParent
+		}
+		projectID = *input.ProjectID
+	}
+	if projectID != current.ProjectID {
+		if err = r.assignableProject(ctx, repository, projectID); err != nil {
+			log.Printf("---Service: failed to update task: %v", err)
+			return err
+		}
+	}
+	now := r.timestamp()
 	task := &entity.Task{
-		Title:       title,
-		Description: description,
+		ID:          correctID,
+		Title:       input.Title,
+		Description: input.Description,
 		Finished:    finished,
+		Status:      status,
+		ProjectID:   projectID,
+		ParentID:    parentID,
+		BlockedBy:   current.BlockedBy,
+		Recurrence:  input.Recurrence,
+		SeriesID:    current.SeriesID,
+		OwnerID:     current.OwnerID,
+		CreatedBy:   current.CreatedBy,
+		CreatedAt:   current.CreatedAt,
+		UpdatedAt:   now,
+		CompletedAt: current.CompletedAt,
+		DueAt:       input.DueAt,
+		DueAllDay:   input.DueAllDay,
+		Priority:    input.Priority,
+		Tags:        input.Tags,
 	}
-	if err = r.repository.Update(correctID, task); err != nil {
+	if !finished {
+		task.CompletedAt = nil
+	} else if finishing || current.CompletedAt == nil {
+		task.CompletedAt = &now
+	}
+	var cascade, added []*entity.Task
+	if finishing {
+		if cascade, added, err = r.finishSubtree(repository, task, input.Force, now); err != nil {
+			log.Printf("---Service: failed to update task: %v", err)
+			return err
+		}
+	}
+	if err = repository.UpdateSubtree(correctID, task, cascade, added); err != nil {
 		log.Printf("---Service: failed to update task to repository: %v", err)
 		return err
 	}
+	if len(added) > 0 {
+		log.Println("---Service: next occurrence added successfully")
+	}
 	log.Println("---Service: task updated successfully")
 	return nil
 }
-func (r *tasksService) GetTask(id string) (*entity.Task, error) {
+func (r *tasksService) GetTask(ctx context.Context, id string) (*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get task: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get task: %v", err)
+		return nil, err
+	}
 	correctID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
 		log.Printf("---Service: failed to get task: %v", ErrInvalidID)
 		return nil, ErrInvalidID
 	}
-	task, err := r.repository.Get(correctID)
+	task, err := r.ownedTask(ctx, repository, correctID)
 	if err != nil {
 		log.Printf("---Service: failed to get task from repository: %v", err)
 		return nil, err
 	}
 	log.Println("---Service: task got successfully")
-	return task, nil
+	return withProgress(repository, task), nil
 }
-func (r *tasksService) GetAllTasks() ([]*entity.Task, error) {
-	tasks, err := r.repository.GetAll()
+func (r *tasksService) GetAllTasks(ctx context.Context, filter TaskFilter) ([]*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get all tasks: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get all tasks: %v", err)
+		return nil, err
+	}
+	for _, status := range filter.Statuses {
+		if !r.workflow.Valid(status) {
+			log.Printf("---Service: failed to get all tasks: %v", ErrInvalidStatus)
+			return nil, ErrInvalidStatus
+		}
+	}
+	if filter.Project != nil {
+		if _, err = r.ownedProject(ctx, repository, *filter.Project); err != nil {
+			log.Printf("---Service: failed to get all tasks: %v", err)
+			return nil, err
+		}
+	}
+	visible, err := r.findTasks(ctx, repository, filter)
 	if err != nil {
 		log.Printf("---Service: failed to get all tasks from repository: %v", err)
 		return nil, err
 	}
+	for i, task := range visible {
+		visible[i] = withProgress(repository, task)
+	}
 	log.Println("---Service: tasks got successfully")
-	return tasks, nil
+	return visible, nil
+}
+
+func (r *tasksService) visibleTasks(ctx context.Context, repository Repository, filter TaskFilter) ([]*entity.Task, error) {
+	tasks, err := r.findTasks(ctx, repository, filter)
+	if errors.Is(err, inmemory.ErrStorageEmpty) {
+		return []*entity.Task{}, nil
+	}
+	return tasks, err
+}
+
+func (r *tasksService) findTasks(ctx context.Context, repository Repository, filter TaskFilter) ([]*entity.Task, error) {
+	var tasks []*entity.Task
+	var err error
+	switch {
+	case len(filter.Tags) > 0:
+		tasks, err = repository.FindByTags(filter.Tags, !filter.AnyTag)
+	case filter.Project != nil:
+		tasks, err = repository.FindByProject(*filter.Project)
+	default:
+		tasks, err = repository.GetAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	visible := tasks[:0:0]
+	for _, task := range tasks {
+		if r.canAccess(ctx, task) && r.matches(repository, filter, task, now) {
+			visible = append(visible, task)
+		}
+	}
+	sortTasks(visible, filter.Sort, filter.location())
+	return visible, nil
 }
 
-func (r *tasksService) DeleteTask(id string) error {
+func (r *tasksService) matches(repository Repository, filter TaskFilter, task *entity.Task, now time.Time) bool {
+	if !filter.Match(task, now) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, r.workflow.statusOf(task)) {
+		return false
+	}
+	return filter.Blocked == nil || r.blocked(repository, task) == *filter.Blocked
+}
+
+func (r *tasksService) DeleteTask(ctx context.Context, id string) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksDelete); err != nil {
+		log.Printf("---Service: failed to delete task: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to delete task: %v", err)
+		return err
+	}
 	correctID, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
 		log.Printf("---Service: failed to delete task: %v", ErrInvalidID)
 		return ErrInvalidID
 	}
-	if err = r.repository.Delete(correctID); err != nil {
+	if _, err = r.ownedTask(ctx, repository, correctID); err != nil {
+		log.Printf("---Service: failed to delete task: %v", err)
+		return err
+	}
+	if err = repository.Delete(correctID); err != nil {
 		log.Printf("---Service: failed to delete task from repository: %v", err)
 		return err
 	}
 	log.Println("---Service: task deleted successfully")
 	return nil
 }
-func (r *tasksService) DeleteAllTasks() error {
-	if err := r.repository.DeleteAll(); err != nil {
+func (r *tasksService) DeleteAllTasks(ctx context.Context) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksDeleteAll); err != nil {
+		log.Printf("---Service: failed to delete all tasks: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to delete all tasks: %v", err)
+		return err
+	}
+	if err := r.authorizer.Authorize(ctx, ActionTasksManageAll); err != nil {
+		return r.deleteOwnedTasks(ctx, repository)
+	}
+	if err = repository.DeleteAll(); err != nil {
 		log.Printf("---Service: failed to delete all tasks from repository: %v", err)
 		return err
 	}
 	log.Println("---Service: tasks deleted successfully")
 	return nil
 }
+
+func (r *tasksService) deleteOwnedTasks(ctx context.Context, repository Repository) error {
+	tasks, err := r.GetAllTasks(ctx, TaskFilter{})
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if _, err := repository.Get(task.ID); err != nil {
+			continue
+		}
+		if err := repository.Delete(task.ID); err != nil {
+			log.Printf("---Service: failed to delete task from repository: %v", err)
+			return err
+		}
+	}
+	log.Println("---Service: own tasks deleted successfully")
+	return nil
+}
+
+func validateInput(input TaskInput) (TaskInput, error) {
+	if input.Title == "" {
+		return input, ErrInvalidTitle
+	}
+	if input.DueAt == nil && input.DueAllDay {
+		return input, ErrInvalidDue
+	}
+	if input.DueAt != nil {
+		if year := input.DueAt.Year(); year < 1970 || year > 9999 {
+			return input, ErrInvalidDue
+		}
+		if input.DueAllDay && !input.DueAt.Equal(input.DueAt.UTC().Truncate(24*time.Hour)) {
+			return input, ErrInvalidDue
+		}
+	}
+	priority, err := NormalizePriority(input.Priority)
+	if err != nil {
+		return input, err
+	}
+	input.Priority = priority
+	if input.Tags, err = NormalizeTags(input.Tags); err != nil {
+		return input, err
+	}
+	if input.Recurrence, err = NormalizeRecurrence(input.Recurrence, input.DueAt, input.DueAllDay); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (r *tasksService) GetAgenda(ctx context.Context, loc *time.Location) (*Agenda, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get agenda: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get agenda: %v", err)
+		return nil, err
+	}
+	tasks, err := r.visibleTasks(ctx, repository, TaskFilter{})
+	if err != nil {
+		log.Printf("---Service: failed to get agenda from repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: agenda got successfully")
+	return buildAgenda(tasks, r.now(), loc), nil
+}
+
+func (r *tasksService) NextTask(ctx context.Context, loc *time.Location) (*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get next task: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get next task: %v", err)
+		return nil, err
+	}
+	tasks, err := r.visibleTasks(ctx, repository, TaskFilter{Sort: SortPriority, Location: loc})
+	if err != nil {
+		log.Printf("---Service: failed to get next task from repository: %v", err)
+		return nil, err
+	}
+	for _, task := range tasks {
+		if !task.Finished {
+			log.Println("---Service: next task got successfully")
+			return task, nil
+		}
+	}
+	log.Printf("---Service: failed to get next task: %v", ErrNoTasks)
+	return nil, ErrNoTasks
+}
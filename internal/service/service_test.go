@@ -1,12 +1,19 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"testing"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
 	"webServerEx/internal/entity"
+	"webServerEx/internal/tenant"
 )
 
+var testAuthorizer, _ = NewAuthorizer(nil)
+
 type mockRepository struct{}
 
 func (m mockRepository) Add(task *entity.Task) error {
@@ -22,7 +29,7 @@ func (m mockRepository) DeleteAll() error {
 }
 
 func (m mockRepository) Get(id uint64) (*entity.Task, error) {
-	return nil, nil
+	return &entity.Task{ID: id}, nil
 }
 
 func (m mockRepository) GetAll() ([]*entity.Task, error) {
@@ -33,10 +40,97 @@ func (m mockRepository) Update(id uint64, task *entity.Task) error {
 	return nil
 }
 
+func (m mockRepository) FindByTags(tags []string, matchAll bool) ([]*entity.Task, error) {
+	return nil, nil
+}
+
+func (m mockRepository) TagCounts() (map[string]int, error) {
+	return nil, nil
+}
+
+func (m mockRepository) RenameTag(from, to string, updatedAt time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m mockRepository) AddProject(project *entity.Project) error {
+	return nil
+}
+
+func (m mockRepository) GetProject(id uint64) (*entity.Project, error) {
+	if id == entity.InboxProjectID {
+		return entity.NewInbox(), nil
+	}
+	return nil, inmemory.ErrProjectNotFound
+}
+
+func (m mockRepository) GetProjects() ([]*entity.Project, error) {
+	return []*entity.Project{entity.NewInbox()}, nil
+}
+
+func (m mockRepository) UpdateProject(id uint64, project *entity.Project) error {
+	return nil
+}
+
+func (m mockRepository) DeleteProject(id uint64, policy string, updatedAt time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m mockRepository) FindByProject(id uint64) ([]*entity.Task, error) {
+	return nil, nil
+}
+
+func (m mockRepository) Children(id uint64) ([]*entity.Task, error) {
+	return nil, nil
+}
+
+func (m mockRepository) Descendants(id uint64) ([]*entity.Task, error) {
+	return nil, nil
+}
+
+func (m mockRepository) MoveSubtree(id uint64, parentID *uint64, projectID uint64, updatedAt time.Time) error {
+	return nil
+}
+
+func (m mockRepository) UpdateSubtree(id uint64, task *entity.Task, finished, added []*entity.Task) error {
+	return nil
+}
+
+func (m mockRepository) AddDependency(id, blockerID uint64, updatedAt time.Time) error {
+	return nil
+}
+
+func (m mockRepository) RemoveDependency(id, blockerID uint64, updatedAt time.Time) error {
+	return nil
+}
+
+func (m mockRepository) Dependents(id uint64) ([]*entity.Task, error) {
+	return nil, nil
+}
+
+func (m mockRepository) AddComment(comment *entity.Comment) error {
+	return nil
+}
+
+func (m mockRepository) GetComment(id uint64) (*entity.Comment, error) {
+	return nil, nil
+}
+
+func (m mockRepository) ListComments(taskID uint64, offset, limit int) ([]*entity.Comment, int, error) {
+	return nil, 0, nil
+}
+
+func (m mockRepository) UpdateComment(id uint64, comment *entity.Comment) error {
+	return nil
+}
+
+func (m mockRepository) DeleteComment(id uint64) error {
+	return nil
+}
+
 func TestServiceAddTask(t *testing.T) {
 	t.Run("addTask correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -49,7 +143,7 @@ func TestServiceAddTask(t *testing.T) {
 		}
 
 		for _, tt := range tableTests {
-			err := service.AddTask(tt.title, tt.description)
+			err := service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 			if err != nil {
 				t.Error(err.Error())
 			}
@@ -58,7 +152,7 @@ func TestServiceAddTask(t *testing.T) {
 
 	t.Run("addTask wrong task", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -71,7 +165,7 @@ func TestServiceAddTask(t *testing.T) {
 		}
 
 		for _, tt := range tableTests {
-			err := service.AddTask(tt.title, tt.description)
+			err := service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 			if !errors.Is(err, ErrInvalidTitle) {
 				t.Errorf("expected ErrInvalidTitle, got %v", err)
 			}
@@ -82,7 +176,7 @@ func TestServiceAddTask(t *testing.T) {
 func TestServiceDeleteTask(t *testing.T) {
 	t.Run("deleteTask correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -94,11 +188,11 @@ func TestServiceDeleteTask(t *testing.T) {
 			{title: "12345", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 
 		for id, _ := range tableTests {
-			err := service.DeleteTask(strconv.Itoa(id))
+			err := service.DeleteTask(testContext(), strconv.Itoa(id))
 			if err != nil {
 				t.Error(err.Error())
 			}
@@ -107,13 +201,13 @@ func TestServiceDeleteTask(t *testing.T) {
 
 	t.Run("deleteTask wrong task", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 
-		err := service.DeleteTask("-101")
+		err := service.DeleteTask(testContext(), "-101")
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("expected ErrInvalidID, got %v", err)
 		}
-		err = service.DeleteTask("-1")
+		err = service.DeleteTask(testContext(), "-1")
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("expected ErrInvalidID, got %v", err)
 		}
@@ -123,7 +217,7 @@ func TestServiceDeleteTask(t *testing.T) {
 func TestServiceDeleteAllTasks(t *testing.T) {
 	t.Run("deleteAllTasks correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -135,10 +229,10 @@ func TestServiceDeleteAllTasks(t *testing.T) {
 			{title: "12345", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 
-		err := service.DeleteAllTasks()
+		err := service.DeleteAllTasks(testContext())
 		if err != nil {
 			t.Error(err.Error())
 		}
@@ -148,7 +242,7 @@ func TestServiceDeleteAllTasks(t *testing.T) {
 func TestServiceGetTask(t *testing.T) {
 	t.Run("getTask correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -160,11 +254,11 @@ func TestServiceGetTask(t *testing.T) {
 			{title: "12345", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 
 		for id, _ := range tableTests {
-			_, err := service.GetTask(strconv.Itoa(id))
+			_, err := service.GetTask(testContext(), strconv.Itoa(id))
 			if err != nil {
 				t.Error(err.Error())
 			}
@@ -173,13 +267,13 @@ func TestServiceGetTask(t *testing.T) {
 
 	t.Run("getTask wrong task", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 
-		_, err := service.GetTask("-101")
+		_, err := service.GetTask(testContext(), "-101")
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("expected ErrInvalidID, got %v", err)
 		}
-		_, err = service.GetTask("-1")
+		_, err = service.GetTask(testContext(), "-1")
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("expected ErrInvalidID, got %v", err)
 		}
@@ -189,7 +283,7 @@ func TestServiceGetTask(t *testing.T) {
 func TestServiceGetAllTasks(t *testing.T) {
 	t.Run("getAllTasks correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -201,10 +295,10 @@ func TestServiceGetAllTasks(t *testing.T) {
 			{title: "12345", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 
-		_, err := service.GetAllTasks()
+		_, err := service.GetAllTasks(testContext(), TaskFilter{})
 		if err != nil {
 			t.Error(err)
 		}
@@ -214,7 +308,7 @@ func TestServiceGetAllTasks(t *testing.T) {
 func TestServiceUpdateTask(t *testing.T) {
 	t.Run("updateTask correct tasks", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -224,7 +318,7 @@ func TestServiceUpdateTask(t *testing.T) {
 			{title: "test3", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 		tableTestsUpd := []struct {
 			id          string
@@ -238,7 +332,7 @@ func TestServiceUpdateTask(t *testing.T) {
 		}
 
 		for _, tt := range tableTestsUpd {
-			err := service.UpdateTask(tt.id, tt.title, tt.description, tt.finished)
+			err := service.UpdateTask(testContext(), tt.id, TaskInput{Title: tt.title, Description: tt.description, Finished: tt.finished})
 			if err != nil {
 				t.Error(err.Error())
 			}
@@ -247,7 +341,7 @@ func TestServiceUpdateTask(t *testing.T) {
 
 	t.Run("updateTask wrong task", func(t *testing.T) {
 		storage := mockRepository{}
-		service := NewTasksService(storage)
+		service := NewTasksService(SingleTenant(storage), testAuthorizer)
 		tableTests := []struct {
 			title       string
 			description string
@@ -257,16 +351,196 @@ func TestServiceUpdateTask(t *testing.T) {
 			{title: "test3", description: ""},
 		}
 		for _, tt := range tableTests {
-			service.AddTask(tt.title, tt.description)
+			service.AddTask(testContext(), TaskInput{Title: tt.title, Description: tt.description})
 		}
 
-		err := service.UpdateTask("-1", "update1", "update2", true)
+		err := service.UpdateTask(testContext(), "-1", TaskInput{Title: "update1", Description: "update2", Finished: true})
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("expected ErrInvalidID, got %v", err)
 		}
-		err = service.UpdateTask("1", "", "update2", true)
+		err = service.UpdateTask(testContext(), "1", TaskInput{Title: "", Description: "update2", Finished: true})
 		if !errors.Is(err, ErrInvalidTitle) {
 			t.Errorf("expected ErrInvalidTitle, got %v", err)
 		}
 	})
 }
+
+func testContext() context.Context {
+	return tenant.WithTenant(context.Background(), "default")
+}
+
+func principalContext(id string, scopes ...string) context.Context {
+	return auth.WithPrincipal(testContext(), &auth.Principal{ID: id, Kind: auth.KindSession, Scopes: scopes})
+}
+
+func TestServiceOwnership(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	bob := principalContext("bob", auth.ScopeTasksWrite)
+	admin := principalContext("admin", auth.ScopeTasksAdmin)
+
+	t.Run("owner fields are set", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "alice task"})
+
+		task, err := service.GetTask(alice, "0")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if task.OwnerID != "session:alice" || task.CreatedBy != "session:alice" {
+			t.Errorf("uncorrect owner: %s %s", task.OwnerID, task.CreatedBy)
+		}
+		service.UpdateTask(alice, "0", TaskInput{Title: "renamed", Finished: true})
+		task, _ = service.GetTask(alice, "0")
+		if task.OwnerID != "session:alice" {
+			t.Errorf("owner must survive update: %s", task.OwnerID)
+		}
+	})
+
+	t.Run("others tasks are hidden", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "alice task"})
+		service.AddTask(bob, TaskInput{Title: "bob task"})
+
+		tasks, err := service.GetAllTasks(alice, TaskFilter{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(tasks) != 1 || tasks[0].Title != "alice task" {
+			t.Errorf("uncorrect visible tasks: %v", tasks)
+		}
+		if _, err := service.GetTask(alice, "1"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+		if err := service.UpdateTask(alice, "1", TaskInput{Title: "stolen"}); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+		if err := service.DeleteTask(alice, "1"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("admin sees everything", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "alice task"})
+		service.AddTask(bob, TaskInput{Title: "bob task"})
+
+		tasks, err := service.GetAllTasks(admin, TaskFilter{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(tasks) != 2 {
+			t.Errorf("uncorrect tasks count: %d", len(tasks))
+		}
+		if _, err := service.GetTask(admin, "1"); err != nil {
+			t.Error(err.Error())
+		}
+	})
+
+	t.Run("delete all removes only own tasks", func(t *testing.T) {
+		authorizer, err := NewAuthorizer(Policy{"cleaner": {ActionTasksRead, ActionTasksDeleteAll}})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), authorizer)
+		service.AddTask(alice, TaskInput{Title: "alice task"})
+		service.AddTask(bob, TaskInput{Title: "bob task"})
+		if err := service.DeleteAllTasks(bob); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+
+		carol := auth.WithPrincipal(testContext(), &auth.Principal{ID: "alice", Kind: auth.KindSession, Roles: []string{"cleaner"}})
+		if err := service.DeleteAllTasks(carol); err != nil {
+			t.Fatal(err.Error())
+		}
+		tasks, _ := service.GetAllTasks(admin, TaskFilter{})
+		if len(tasks) != 1 || tasks[0].Title != "bob task" {
+			t.Errorf("uncorrect remaining tasks: %v", tasks)
+		}
+	})
+}
+
+func TestServiceTenants(t *testing.T) {
+	t.Run("tenants are isolated", func(t *testing.T) {
+		storage := inmemory.NewTenantStorage(nil)
+		storage.Provision("team-a")
+		storage.Provision("team-b")
+		repositories := TenantRepositoriesFunc(func(tenantID string) (Repository, error) {
+			tasks, err := storage.ForTenant(tenantID)
+			if err != nil {
+				return nil, err
+			}
+			return NewRepository(tasks), nil
+		})
+		service := NewTasksService(repositories, testAuthorizer)
+		teamA := tenant.WithTenant(context.Background(), "team-a")
+		teamB := tenant.WithTenant(context.Background(), "team-b")
+
+		service.AddTask(teamA, TaskInput{Title: "team a task"})
+		service.AddTask(teamB, TaskInput{Title: "team b task"})
+		task, err := service.GetTask(teamB, "0")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if task.Title != "team b task" {
+			t.Errorf("uncorrect task: %s", task.Title)
+		}
+		if err := service.DeleteAllTasks(teamA); err != nil {
+			t.Fatal(err.Error())
+		}
+		tasks, err := service.GetAllTasks(teamB, TaskFilter{})
+		if err != nil || len(tasks) != 1 {
+			t.Errorf("other tenant must keep its tasks: %v %v", tasks, err)
+		}
+	})
+
+	t.Run("context without tenant", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		if err := service.AddTask(context.Background(), TaskInput{Title: "lost"}); !errors.Is(err, tenant.ErrNoTenant) {
+			t.Errorf("expected ErrNoTenant, got %v", err)
+		}
+	})
+}
+
+func TestServiceTimestamps(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithClock(clock))
+	ctx := testContext()
+
+	service.AddTask(ctx, TaskInput{Title: "task"})
+	created := now
+	task, _ := service.GetTask(ctx, "0")
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(created) || task.CompletedAt != nil {
+		t.Fatalf("uncorrect timestamps after add: %+v", task)
+	}
+
+	now = now.Add(time.Hour)
+	service.UpdateTask(ctx, "0", TaskInput{Title: "task", Finished: true})
+	task, _ = service.GetTask(ctx, "0")
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(now) || task.CompletedAt == nil || !task.CompletedAt.Equal(now) {
+		t.Fatalf("uncorrect timestamps after completion: %+v", task)
+	}
+	completed := now
+
+	now = now.Add(time.Hour)
+	service.UpdateTask(ctx, "0", TaskInput{Title: "renamed", Finished: true})
+	task, _ = service.GetTask(ctx, "0")
+	if !task.CompletedAt.Equal(completed) {
+		t.Errorf("completed_at must not move while task stays finished: %v", task.CompletedAt)
+	}
+
+	tasks, _ := service.GetAllTasks(ctx, TaskFilter{UpdatedSince: now.Add(time.Minute)})
+	if len(tasks) != 0 {
+		t.Errorf("expected no tasks updated since the future, got %d", len(tasks))
+	}
+	tasks, _ = service.GetAllTasks(ctx, TaskFilter{CompletedSince: completed})
+	if len(tasks) != 1 {
+		t.Errorf("expected 1 completed task, got %d", len(tasks))
+	}
+
+	service.UpdateTask(ctx, "0", TaskInput{Title: "renamed"})
+	task, _ = service.GetTask(ctx, "0")
+	if task.CompletedAt != nil {
+		t.Errorf("completed_at must be cleared when task is reopened: %v", task.CompletedAt)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+const (
+	SortID       = "id"
+	SortPriority = "priority"
+	SortDue      = "due"
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+)
+
+func ValidSort(sort string) bool {
+	switch strings.TrimPrefix(sort, "-") {
+	case SortID, SortPriority, SortDue, SortCreated, SortUpdated:
+		return true
+	}
+	return false
+}
+
+func compareDue(a, b *entity.Task, loc *time.Location) int {
+	switch {
+	case a.DueAt == nil && b.DueAt == nil:
+		return 0
+	case a.DueAt == nil:
+		return 1
+	case b.DueAt == nil:
+		return -1
+	}
+	return dueStart(a, loc).Compare(dueStart(b, loc))
+}
+
+func compareTasks(sort string, a, b *entity.Task, loc *time.Location) int {
+	var c int
+	switch sort {
+	case SortPriority:
+		c = cmp.Or(cmp.Compare(priorityRank(a), priorityRank(b)), compareDue(a, b, loc))
+	case SortDue:
+		c = cmp.Or(compareDue(a, b, loc), cmp.Compare(priorityRank(a), priorityRank(b)))
+	case SortCreated:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortUpdated:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+}
+
+func sortTasks(tasks []*entity.Task, sort string, loc *time.Location) {
+	key, descending := strings.CutPrefix(sort, "-")
+	slices.SortStableFunc(tasks, func(a, b *entity.Task) int {
+		if descending {
+			return compareTasks(key, b, a, loc)
+		}
+		return compareTasks(key, a, b, loc)
+	})
+}
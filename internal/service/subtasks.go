@@ -0,0 +1,220 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+	"webServerEx/internal/entity"
+)
+
+var (
+	ErrParentNotFound = errors.New("parent task not found")
+	ErrInvalidParent  = errors.New("invalid parent task")
+)
+
+type TaskTree struct {
+	*entity.Task
+	Children []*TaskTree `json:"children,omitempty"`
+}
+
+func progress(children []*entity.Task) *entity.Progress {
+	if len(children) == 0 {
+		return nil
+	}
+	result := &entity.Progress{Total: len(children)}
+	for _, child := range children {
+		if child.Finished {
+			result.Finished++
+		}
+	}
+	return result
+}
+
+func withProgress(repository Repository, task *entity.Task) *entity.Task {
+	children, err := repository.Children(task.ID)
+	if err != nil || len(children) == 0 {
+		return task
+	}
+	result := *task
+	result.Progress = progress(children)
+	return &result
+}
+
+func (r *tasksService) parentProject(ctx context.Context, repository Repository, parentID uint64, projectID *uint64) (uint64, error) {
+	parent, err := r.ownedTask(ctx, repository, parentID)
+	if err != nil {
+		return 0, ErrParentNotFound
+	}
+	if projectID != nil && *projectID != parent.ProjectID {
+		return 0, ErrInvalidParent
+	}
+	return parent.ProjectID, nil
+}
+
+func (r *tasksService) finishSubtree(repository Repository, root *entity.Task, force bool, now time.Time) ([]*entity.Task, []*entity.Task, error) {
+	descendants, err := repository.Descendants(root.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	closing := map[uint64]bool{root.ID: true}
+	tasks := []*entity.Task{root}
+	for _, descendant := range descendants {
+		if descendant.Finished {
+			continue
+		}
+		from := r.workflow.statusOf(descendant)
+		if !r.workflow.Allowed(from, root.Status) {
+			return nil, nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, root.Status)
+		}
+		task := *descendant
+		task.Finished = true
+		task.Status = root.Status
+		task.ProjectID = root.ProjectID
+		task.CompletedAt = &now
+		task.UpdatedAt = now
+		closing[task.ID] = true
+		tasks = append(tasks, &task)
+	}
+	var added []*entity.Task
+	for _, task := range tasks {
+		if task.Status == r.workflow.done && !force && r.blockedOutside(repository, task, closing) {
+			return nil, nil, ErrTaskBlocked
+		}
+		if next := r.rollover(task, now); next != nil {
+			added = append(added, next)
+		}
+	}
+	return tasks[1:], added, nil
+}
+
+func (r *tasksService) GetChildren(ctx context.Context, id string) ([]*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get subtasks: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get subtasks: %v", err)
+		return nil, err
+	}
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Printf("---Service: failed to get subtasks: %v", ErrInvalidID)
+		return nil, ErrInvalidID
+	}
+	if _, err = r.ownedTask(ctx, repository, correctID); err != nil {
+		log.Printf("---Service: failed to get subtasks: %v", err)
+		return nil, err
+	}
+	children, err := repository.Children(correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get subtasks from repository: %v", err)
+		return nil, err
+	}
+	visible := children[:0:0]
+	for _, child := range children {
+		if r.canAccess(ctx, child) {
+			visible = append(visible, withProgress(repository, child))
+		}
+	}
+	slices.SortFunc(visible, func(a, b *entity.Task) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	log.Println("---Service: subtasks got successfully")
+	return visible, nil
+}
+
+func (r *tasksService) GetSubtree(ctx context.Context, id string) (*TaskTree, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to get subtree: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to get subtree: %v", err)
+		return nil, err
+	}
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Printf("---Service: failed to get subtree: %v", ErrInvalidID)
+		return nil, ErrInvalidID
+	}
+	root, err := r.ownedTask(ctx, repository, correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get subtree: %v", err)
+		return nil, err
+	}
+	descendants, err := repository.Descendants(correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get subtree from repository: %v", err)
+		return nil, err
+	}
+	children := make(map[uint64][]*entity.Task)
+	for _, task := range descendants {
+		children[*task.ParentID] = append(children[*task.ParentID], task)
+	}
+	tree := r.buildTree(ctx, root, children)
+	log.Println("---Service: subtree got successfully")
+	return tree, nil
+}
+
+func (r *tasksService) buildTree(ctx context.Context, task *entity.Task, children map[uint64][]*entity.Task) *TaskTree {
+	node := *task
+	node.Progress = progress(children[task.ID])
+	tree := &TaskTree{Task: &node}
+	for _, child := range children[task.ID] {
+		if r.canAccess(ctx, child) {
+			tree.Children = append(tree.Children, r.buildTree(ctx, child, children))
+		}
+	}
+	slices.SortFunc(tree.Children, func(a, b *TaskTree) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return tree
+}
+
+func (r *tasksService) MoveTask(ctx context.Context, id string, parentID *uint64) error {
+	if err := r.authorizer.Authorize(ctx, ActionTasksUpdate); err != nil {
+		log.Printf("---Service: failed to move task: %v", err)
+		return err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to move task: %v", err)
+		return err
+	}
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Printf("---Service: failed to move task: %v", ErrInvalidID)
+		return ErrInvalidID
+	}
+	current, err := r.ownedTask(ctx, repository, correctID)
+	if err != nil {
+		log.Printf("---Service: failed to move task: %v", err)
+		return err
+	}
+	projectID := current.ProjectID
+	if parentID != nil {
+		if projectID, err = r.parentProject(ctx, repository, *parentID, nil); err != nil {
+			log.Printf("---Service: failed to move task: %v", err)
+			return err
+		}
+	}
+	if projectID != current.ProjectID {
+		if err = r.assignableProject(ctx, repository, projectID); err != nil {
+			log.Printf("---Service: failed to move task: %v", err)
+			return err
+		}
+	}
+	if err = repository.MoveSubtree(correctID, parentID, projectID, r.timestamp()); err != nil {
+		log.Printf("---Service: failed to move task in repository: %v", err)
+		return err
+	}
+	log.Println("---Service: task moved successfully")
+	return nil
+}
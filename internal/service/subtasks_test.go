@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+	"webServerEx/internal/entity"
+)
+
+func TestServiceSubtasks(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	bob := principalContext("bob", auth.ScopeTasksWrite)
+	newService := func() Service {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		root, child := uint64(0), uint64(1)
+		service.AddTask(alice, TaskInput{Title: "release"})
+		service.AddTask(alice, TaskInput{Title: "build", ParentID: &root})
+		service.AddTask(alice, TaskInput{Title: "test", ParentID: &root, Finished: true})
+		service.AddTask(alice, TaskInput{Title: "unit", ParentID: &child})
+		return service
+	}
+
+	t.Run("parent shows progress", func(t *testing.T) {
+		service := newService()
+		task, _ := service.GetTask(alice, "0")
+		if task.Progress == nil || task.Progress.Finished != 1 || task.Progress.Total != 2 {
+			t.Errorf("uncorrect progress: %+v", task.Progress)
+		}
+		children, _ := service.GetChildren(alice, "0")
+		if len(children) != 2 || children[0].Title != "build" || children[0].Progress.Total != 1 {
+			t.Errorf("uncorrect children: %v", children)
+		}
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+		service := newService()
+		tree, err := service.GetSubtree(alice, "0")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(tree.Children) != 2 || len(tree.Children[0].Children) != 1 || tree.Children[0].Children[0].Title != "unit" {
+			t.Errorf("uncorrect subtree: %+v", tree)
+		}
+		if _, err := service.GetSubtree(bob, "0"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("move guards against cycles", func(t *testing.T) {
+		service := newService()
+		leaf := uint64(3)
+		if err := service.MoveTask(alice, "0", &leaf); !errors.Is(err, inmemory.ErrTaskCycle) {
+			t.Errorf("expected ErrTaskCycle, got %v", err)
+		}
+		if err := service.MoveTask(alice, "1", nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		tree, _ := service.GetSubtree(alice, "1")
+		if tree.ParentID != nil || len(tree.Children) != 1 {
+			t.Errorf("uncorrect moved subtree: %+v", tree)
+		}
+		bobs := uint64(4)
+		service.AddTask(bob, TaskInput{Title: "other"})
+		if err := service.MoveTask(alice, "1", &bobs); !errors.Is(err, ErrParentNotFound) {
+			t.Errorf("expected ErrParentNotFound, got %v", err)
+		}
+	})
+
+	t.Run("move subtree to another project", func(t *testing.T) {
+		service := newService()
+		work, _ := service.AddProject(alice, ProjectInput{Name: "Work"})
+		if err := service.UpdateTask(alice, "1", TaskInput{Title: "build", ProjectID: &work.ID}); !errors.Is(err, ErrInvalidParent) {
+			t.Errorf("expected ErrInvalidParent, got %v", err)
+		}
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "release", ProjectID: &work.ID}); err != nil {
+			t.Fatal(err.Error())
+		}
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{Project: &work.ID})
+		if len(tasks) != 4 {
+			t.Errorf("expected whole subtree in project, got %d tasks", len(tasks))
+		}
+	})
+
+	t.Run("finishing parent finishes children", func(t *testing.T) {
+		service := newService()
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "release", Finished: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "3")
+		if !task.Finished || task.CompletedAt == nil {
+			t.Errorf("expected finished subtask, got %+v", task)
+		}
+	})
+
+	t.Run("finishing parent rejects blocked subtask", func(t *testing.T) {
+		service := newService()
+		work, _ := service.AddProject(alice, ProjectInput{Name: "Work"})
+		service.AddTask(alice, TaskInput{Title: "design"})
+		if err := service.AddDependency(alice, "3", "4"); err != nil {
+			t.Fatal(err.Error())
+		}
+		err := service.UpdateTask(alice, "0", TaskInput{Title: "release", ProjectID: &work.ID, Finished: true})
+		if !errors.Is(err, ErrTaskBlocked) {
+			t.Errorf("expected ErrTaskBlocked, got %v", err)
+		}
+		task, _ := service.GetTask(alice, "0")
+		if task.Finished || task.ProjectID == work.ID {
+			t.Errorf("expected unchanged task, got %+v", task)
+		}
+		if task, _ = service.GetTask(alice, "1"); task.Finished {
+			t.Errorf("expected unfinished subtask, got %+v", task)
+		}
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "release", Finished: true, Force: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+
+	t.Run("finishing parent ignores blockers inside subtree", func(t *testing.T) {
+		service := newService()
+		if err := service.AddDependency(alice, "3", "1"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "release", Finished: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+
+	t.Run("finishing parent checks subtask transitions", func(t *testing.T) {
+		workflow, err := NewWorkflow(WorkflowOptions{
+			Statuses:    []string{"open", "doing", "closed"},
+			Done:        "closed",
+			Transitions: map[string][]string{"open": {"doing"}, "doing": {"closed"}},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithWorkflow(workflow))
+		root := uint64(0)
+		service.AddTask(alice, TaskInput{Title: "release", Status: "doing"})
+		service.AddTask(alice, TaskInput{Title: "build", ParentID: &root})
+		if _, err = service.TransitionTask(alice, "0", "closed", false); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+		if task, _ := service.GetTask(alice, "0"); task.Finished {
+			t.Errorf("expected unfinished task, got %+v", task)
+		}
+	})
+
+	t.Run("finishing parent rolls over recurring subtask", func(t *testing.T) {
+		service := newService()
+		root, due := uint64(0), time.Now().Add(time.Hour)
+		err := service.AddTask(alice, TaskInput{Title: "standup", ParentID: &root, DueAt: &due, Recurrence: &entity.Recurrence{Frequency: "daily"}})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "release", Finished: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		finished, _ := service.GetTask(alice, "4")
+		if !finished.Finished || finished.Recurrence != nil || finished.SeriesID == nil {
+			t.Errorf("uncorrect finished occurrence: %+v", finished)
+		}
+		next, err := service.GetTask(alice, "5")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if next.Title != "standup" || next.Finished || next.Recurrence == nil || next.SeriesID == nil || *next.SeriesID != 4 {
+			t.Errorf("uncorrect next occurrence: %+v", next)
+		}
+	})
+
+	t.Run("deleting parent deletes subtree", func(t *testing.T) {
+		service := newService()
+		if err := service.DeleteTask(alice, "1"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := service.GetTask(alice, "3"); !errors.Is(err, inmemory.ErrTaskNotFound) {
+			t.Errorf("expected ErrTaskNotFound, got %v", err)
+		}
+	})
+
+	t.Run("delete own tasks with subtasks", func(t *testing.T) {
+		authorizer, err := NewAuthorizer(Policy{"cleaner": {ActionTasksRead, ActionTasksCreate, ActionTasksDeleteAll}})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		cleaner := auth.WithPrincipal(testContext(), &auth.Principal{ID: "alice", Kind: auth.KindSession, Roles: []string{"cleaner"}})
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), authorizer)
+		root := uint64(0)
+		service.AddTask(cleaner, TaskInput{Title: "release"})
+		service.AddTask(cleaner, TaskInput{Title: "build", ParentID: &root})
+		if err := service.DeleteAllTasks(cleaner); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := service.GetAllTasks(cleaner, TaskFilter{}); !errors.Is(err, inmemory.ErrStorageEmpty) {
+			t.Errorf("expected ErrStorageEmpty, got %v", err)
+		}
+	})
+}
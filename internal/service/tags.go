@@ -0,0 +1,125 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 32
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+	if tag == "" || len(tag) > maxTagLength {
+		return "", ErrInvalidTag
+	}
+	for _, ch := range tag {
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_' || ch == '.' || ch == ':') {
+			return "", ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
+
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > maxTags {
+		return nil, ErrInvalidTag
+	}
+	return normalized, nil
+}
+
+func (r *tasksService) ListTags(ctx context.Context) ([]TagCount, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to list tags: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to list tags: %v", err)
+		return nil, err
+	}
+	counts, err := repository.TagCounts()
+	if err == nil && len(counts) > 0 && r.authorizer.Authorize(ctx, ActionTasksManageAll) != nil {
+		counts, err = r.ownTagCounts(ctx, repository)
+	}
+	if err != nil {
+		log.Printf("---Service: failed to list tags from repository: %v", err)
+		return nil, err
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Tag, b.Tag))
+	})
+	log.Println("---Service: tags listed successfully")
+	return tags, nil
+}
+
+func (r *tasksService) ownTagCounts(ctx context.Context, repository Repository) (map[string]int, error) {
+	tasks, err := r.visibleTasks(ctx, repository, TaskFilter{})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *tasksService) RenameTag(ctx context.Context, from, to string) (int, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTagsManage); err != nil {
+		log.Printf("---Service: failed to rename tag: %v", err)
+		return 0, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to rename tag: %v", err)
+		return 0, err
+	}
+	if from, err = NormalizeTag(from); err != nil {
+		log.Printf("---Service: failed to rename tag: %v", err)
+		return 0, err
+	}
+	if to, err = NormalizeTag(to); err != nil {
+		log.Printf("---Service: failed to rename tag: %v", err)
+		return 0, err
+	}
+	renamed, err := repository.RenameTag(from, to, r.timestamp())
+	if err != nil {
+		log.Printf("---Service: failed to rename tag in repository: %v", err)
+		return 0, err
+	}
+	log.Printf("---Service: tag renamed successfully")
+	return renamed, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Work ", "home", "work", "Deep Focus"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tags) != 3 || tags[0] != "deep-focus" || tags[1] != "home" || tags[2] != "work" {
+		t.Errorf("uncorrect tags: %v", tags)
+	}
+	if _, err := NormalizeTags([]string{"no/slashes"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
+
+func TestServiceTags(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	bob := principalContext("bob", auth.ScopeTasksWrite)
+	admin := principalContext("admin", auth.ScopeTasksAdmin)
+	service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+	service.AddTask(alice, TaskInput{Title: "report", Tags: []string{"work", "urgent"}})
+	service.AddTask(alice, TaskInput{Title: "groceries", Tags: []string{"home"}})
+	service.AddTask(bob, TaskInput{Title: "deploy", Tags: []string{"work"}})
+
+	t.Run("list filters by tags", func(t *testing.T) {
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{Tags: []string{"work", "urgent"}})
+		if len(tasks) != 1 || tasks[0].Title != "report" {
+			t.Errorf("uncorrect AND result: %v", tasks)
+		}
+		tasks, _ = service.GetAllTasks(alice, TaskFilter{Tags: []string{"work", "home"}, AnyTag: true})
+		if len(tasks) != 2 {
+			t.Errorf("expected 2 own tasks, got %d", len(tasks))
+		}
+	})
+
+	t.Run("counts respect ownership", func(t *testing.T) {
+		tags, _ := service.ListTags(alice)
+		if len(tags) != 3 || tags[0].Tag != "home" || tags[0].Count != 1 {
+			t.Errorf("uncorrect own tags: %v", tags)
+		}
+		tags, _ = service.ListTags(admin)
+		if tags[0].Tag != "work" || tags[0].Count != 2 {
+			t.Errorf("uncorrect all tags: %v", tags)
+		}
+	})
+
+	t.Run("rename requires tags:manage", func(t *testing.T) {
+		if _, err := service.RenameTag(alice, "work", "job"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		renamed, err := service.RenameTag(admin, "work", "Job")
+		if err != nil || renamed != 2 {
+			t.Fatalf("uncorrect rename: %d %v", renamed, err)
+		}
+		tasks, _ := service.GetAllTasks(bob, TaskFilter{Tags: []string{"job"}})
+		if len(tasks) != 1 {
+			t.Errorf("expected renamed tag on bob's task, got %d", len(tasks))
+		}
+	})
+}
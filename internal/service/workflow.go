@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"webServerEx/internal/entity"
+)
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+	ErrInvalidWorkflow   = errors.New("invalid workflow")
+)
+
+type WorkflowOptions struct {
+	Statuses    []string
+	Initial     string
+	Done        string
+	Terminal    []string
+	Transitions map[string][]string
+}
+
+type Workflow struct {
+	statuses    []string
+	initial     string
+	done        string
+	terminal    []string
+	transitions map[string][]string
+}
+
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		statuses: []string{StatusTodo, StatusInProgress, StatusReview, StatusDone, StatusCancelled},
+		initial:  StatusTodo,
+		done:     StatusDone,
+		terminal: []string{StatusDone, StatusCancelled},
+		transitions: map[string][]string{
+			StatusTodo:       {StatusInProgress, StatusDone, StatusCancelled},
+			StatusInProgress: {StatusTodo, StatusReview, StatusDone, StatusCancelled},
+			StatusReview:     {StatusInProgress, StatusDone, StatusCancelled},
+			StatusDone:       {StatusTodo},
+			StatusCancelled:  {StatusTodo},
+		},
+	}
+}
+
+func NewWorkflow(opts WorkflowOptions) (*Workflow, error) {
+	if len(opts.Statuses) == 0 {
+		return DefaultWorkflow(), nil
+	}
+	w := &Workflow{
+		statuses:    opts.Statuses,
+		initial:     opts.Initial,
+		done:        opts.Done,
+		terminal:    opts.Terminal,
+		transitions: opts.Transitions,
+	}
+	if w.initial == "" {
+		w.initial = w.statuses[0]
+	}
+	if len(w.terminal) == 0 && w.done != "" {
+		w.terminal = []string{w.done}
+	}
+	for i, status := range w.statuses {
+		if status == "" || slices.Contains(w.statuses[:i], status) {
+			return nil, fmt.Errorf("%w: duplicate or empty status %q", ErrInvalidWorkflow, status)
+		}
+	}
+	if !w.Valid(w.initial) || w.Terminal(w.initial) {
+		return nil, fmt.Errorf("%w: initial status %q must be a non-terminal status", ErrInvalidWorkflow, w.initial)
+	}
+	if !w.Valid(w.done) || !w.Terminal(w.done) {
+		return nil, fmt.Errorf("%w: done status %q must be a terminal status", ErrInvalidWorkflow, w.done)
+	}
+	for _, status := range w.terminal {
+		if !w.Valid(status) {
+			return nil, fmt.Errorf("%w: unknown terminal status %q", ErrInvalidWorkflow, status)
+		}
+	}
+	for from, targets := range w.transitions {
+		if !w.Valid(from) {
+			return nil, fmt.Errorf("%w: unknown status %q in transitions", ErrInvalidWorkflow, from)
+		}
+		for _, to := range targets {
+			if !w.Valid(to) {
+				return nil, fmt.Errorf("%w: unknown status %q in transitions from %s", ErrInvalidWorkflow, to, from)
+			}
+		}
+	}
+	return w, nil
+}
+
+func (w *Workflow) Statuses() []string {
+	return slices.Clone(w.statuses)
+}
+
+func (w *Workflow) Valid(status string) bool {
+	return slices.Contains(w.statuses, status)
+}
+
+func (w *Workflow) Terminal(status string) bool {
+	return slices.Contains(w.terminal, status)
+}
+
+func (w *Workflow) Allowed(from, to string) bool {
+	return from == to || slices.Contains(w.transitions[from], to)
+}
+
+func (w *Workflow) statusOf(task *entity.Task) string {
+	if task.Status != "" {
+		return task.Status
+	}
+	if task.Finished {
+		return w.done
+	}
+	return w.initial
+}
+
+func (w *Workflow) initialStatus(input TaskInput) (string, error) {
+	switch {
+	case input.Status != "":
+		if !w.Valid(input.Status) {
+			return "", ErrInvalidStatus
+		}
+		return input.Status, nil
+	case input.Finished:
+		return w.done, nil
+	}
+	return w.initial, nil
+}
+
+func (w *Workflow) nextStatus(current *entity.Task, input TaskInput) (string, error) {
+	from := w.statusOf(current)
+	to := from
+	switch {
+	case input.Status != "":
+		if !w.Valid(input.Status) {
+			return "", ErrInvalidStatus
+		}
+		to = input.Status
+	case input.Finished && !w.Terminal(from):
+		to = w.done
+	case !input.Finished && from == w.done:
+		to = w.initial
+	}
+	if !w.Allowed(from, to) {
+		return "", fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return to, nil
+}
+
+func (r *tasksService) TransitionTask(ctx context.Context, id, status string, force bool) (*entity.Task, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksUpdate); err != nil {
+		log.Printf("---Service: failed to transition task: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to transition task: %v", err)
+		return nil, err
+	}
+	correctID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Printf("---Service: failed to transition task: %v", ErrInvalidID)
+		return nil, ErrInvalidID
+	}
+	if !r.workflow.Valid(status) {
+		log.Printf("---Service: failed to transition task: %v", ErrInvalidStatus)
+		return nil, ErrInvalidStatus
+	}
+	current, err := r.ownedTask(ctx, repository, correctID)
+	if err != nil {
+		log.Printf("---Service: failed to transition task: %v", err)
+		return nil, err
+	}
+	input := TaskInput{
+		Title:       current.Title,
+		Description: current.Description,
+		Status:      status,
+		DueAt:       current.DueAt,
+		DueAllDay:   current.DueAllDay,
+		Priority:    current.Priority,
+		Tags:        current.Tags,
+		Recurrence:  current.Recurrence,
+		Force:       force,
+	}
+	if err = r.UpdateTask(ctx, id, input); err != nil {
+		return nil, err
+	}
+	task, err := repository.Get(correctID)
+	if err != nil {
+		log.Printf("---Service: failed to get transitioned task from repository: %v", err)
+		return nil, err
+	}
+	log.Println("---Service: task transitioned successfully")
+	return withProgress(repository, task), nil
+}
+
+func (r *tasksService) StatusCounts(ctx context.Context, filter TaskFilter) (map[string]int, error) {
+	if err := r.authorizer.Authorize(ctx, ActionTasksRead); err != nil {
+		log.Printf("---Service: failed to count statuses: %v", err)
+		return nil, err
+	}
+	repository, err := r.repository(ctx)
+	if err != nil {
+		log.Printf("---Service: failed to count statuses: %v", err)
+		return nil, err
+	}
+	counts := make(map[string]int, len(r.workflow.statuses))
+	for _, status := range r.workflow.statuses {
+		counts[status] = 0
+	}
+	filter.Statuses = nil
+	tasks, err := r.visibleTasks(ctx, repository, filter)
+	if err != nil {
+		log.Printf("---Service: failed to count statuses from repository: %v", err)
+		return nil, err
+	}
+	for _, task := range tasks {
+		counts[r.workflow.statusOf(task)]++
+	}
+	log.Println("---Service: statuses counted successfully")
+	return counts, nil
+}
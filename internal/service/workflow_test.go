@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"webServerEx/internal/auth"
+	"webServerEx/internal/db/inmemory"
+)
+
+func TestNewWorkflow(t *testing.T) {
+	tests := []struct {
+		name string
+		opts WorkflowOptions
+		err  bool
+	}{
+		{name: "default", opts: WorkflowOptions{}},
+		{name: "custom", opts: WorkflowOptions{
+			Statuses:    []string{"open", "closed"},
+			Done:        "closed",
+			Transitions: map[string][]string{"open": {"closed"}, "closed": {"open"}},
+		}},
+		{name: "duplicate status", opts: WorkflowOptions{Statuses: []string{"open", "open", "closed"}, Done: "closed"}, err: true},
+		{name: "missing done", opts: WorkflowOptions{Statuses: []string{"open", "closed"}}, err: true},
+		{name: "terminal initial", opts: WorkflowOptions{Statuses: []string{"open", "closed"}, Initial: "closed", Done: "closed"}, err: true},
+		{name: "unknown transition", opts: WorkflowOptions{
+			Statuses:    []string{"open", "closed"},
+			Done:        "closed",
+			Transitions: map[string][]string{"open": {"archived"}},
+		}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWorkflow(tt.opts)
+			if tt.err && !errors.Is(err, ErrInvalidWorkflow) {
+				t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+			}
+			if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestServiceWorkflow(t *testing.T) {
+	alice := principalContext("alice", auth.ScopeTasksWrite)
+	newService := func() Service {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		service.AddTask(alice, TaskInput{Title: "write"})
+		service.AddTask(alice, TaskInput{Title: "ship", Finished: true})
+		service.AddTask(alice, TaskInput{Title: "drop", Status: StatusCancelled})
+		return service
+	}
+
+	t.Run("initial statuses", func(t *testing.T) {
+		service := newService()
+		tasks, _ := service.GetAllTasks(alice, TaskFilter{})
+		expected := map[string]string{"write": StatusTodo, "ship": StatusDone, "drop": StatusCancelled}
+		for _, task := range tasks {
+			if task.Status != expected[task.Title] {
+				t.Errorf("uncorrect status of %s: %s", task.Title, task.Status)
+			}
+		}
+	})
+
+	t.Run("transitions", func(t *testing.T) {
+		service := newService()
+		task, err := service.TransitionTask(alice, "0", StatusInProgress, false)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if task.Status != StatusInProgress || task.Finished {
+			t.Errorf("uncorrect task: %+v", task)
+		}
+		if task, _ = service.TransitionTask(alice, "0", StatusReview, false); task.Status != StatusReview {
+			t.Errorf("uncorrect status: %s", task.Status)
+		}
+		task, err = service.TransitionTask(alice, "0", StatusDone, false)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !task.Finished || task.CompletedAt == nil {
+			t.Errorf("expected finished task, got %+v", task)
+		}
+		if _, err = service.TransitionTask(alice, "0", StatusReview, false); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+		if _, err = service.TransitionTask(alice, "0", "archived", false); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("expected ErrInvalidStatus, got %v", err)
+		}
+	})
+
+	t.Run("finished compatibility", func(t *testing.T) {
+		service := newService()
+		if err := service.UpdateTask(alice, "0", TaskInput{Title: "write", Finished: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "0")
+		if task.Status != StatusDone || !task.Finished {
+			t.Errorf("uncorrect task: %+v", task)
+		}
+		if err := service.UpdateTask(alice, "2", TaskInput{Title: "drop", Finished: true}); err != nil {
+			t.Fatal(err.Error())
+		}
+		if task, _ = service.GetTask(alice, "2"); task.Status != StatusCancelled {
+			t.Errorf("expected cancelled task to stay cancelled, got %s", task.Status)
+		}
+		if err := service.UpdateTask(alice, "1", TaskInput{Title: "ship"}); err != nil {
+			t.Fatal(err.Error())
+		}
+		if task, _ = service.GetTask(alice, "1"); task.Status != StatusTodo || task.Finished || task.CompletedAt != nil {
+			t.Errorf("uncorrect reopened task: %+v", task)
+		}
+	})
+
+	t.Run("legacy update keeps cancelled task", func(t *testing.T) {
+		service := newService()
+		if err := service.UpdateTask(alice, "2", TaskInput{Title: "dropped"}); err != nil {
+			t.Fatal(err.Error())
+		}
+		task, _ := service.GetTask(alice, "2")
+		if task.Title != "dropped" || task.Status != StatusCancelled || !task.Finished {
+			t.Errorf("expected cancelled task to stay cancelled, got %+v", task)
+		}
+		if err := service.UpdateTask(alice, "2", TaskInput{Title: "dropped", Status: StatusTodo}); err != nil {
+			t.Fatal(err.Error())
+		}
+		if task, _ = service.GetTask(alice, "2"); task.Status != StatusTodo || task.Finished {
+			t.Errorf("uncorrect reopened task: %+v", task)
+		}
+	})
+
+	t.Run("status filter and counts", func(t *testing.T) {
+		service := newService()
+		tasks, err := service.GetAllTasks(alice, TaskFilter{Statuses: []string{StatusTodo, StatusCancelled}})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(tasks) != 2 {
+			t.Errorf("uncorrect filtered tasks: %v", tasks)
+		}
+		if _, err = service.GetAllTasks(alice, TaskFilter{Statuses: []string{"archived"}}); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("expected ErrInvalidStatus, got %v", err)
+		}
+		counts, err := service.StatusCounts(alice, TaskFilter{Statuses: []string{StatusDone}})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(counts) != 5 || counts[StatusTodo] != 1 || counts[StatusDone] != 1 || counts[StatusCancelled] != 1 || counts[StatusReview] != 0 {
+			t.Errorf("uncorrect counts: %v", counts)
+		}
+	})
+
+	t.Run("counts of empty storage", func(t *testing.T) {
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer)
+		counts, err := service.StatusCounts(alice, TaskFilter{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(counts) != 5 || counts[StatusTodo] != 0 || counts[StatusDone] != 0 {
+			t.Errorf("uncorrect counts: %v", counts)
+		}
+	})
+
+	t.Run("custom workflow", func(t *testing.T) {
+		workflow, err := NewWorkflow(WorkflowOptions{
+			Statuses:    []string{"open", "closed"},
+			Done:        "closed",
+			Transitions: map[string][]string{"open": {"closed"}},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		service := NewTasksService(SingleTenant(NewRepository(inmemory.NewStorage())), testAuthorizer, WithWorkflow(workflow))
+		service.AddTask(alice, TaskInput{Title: "write"})
+		if task, _ := service.TransitionTask(alice, "0", "closed", false); task == nil || !task.Finished {
+			t.Errorf("expected finished task, got %+v", task)
+		}
+		if _, err = service.TransitionTask(alice, "0", "open", false); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"webServerEx/internal/auth"
+)
+
+const DefaultHeader = "X-Tenant-ID"
+
+var (
+	ErrInvalidTenant  = errors.New("invalid tenant id")
+	ErrTenantMismatch = errors.New("tenant does not match credentials")
+	ErrNoTenant       = errors.New("no tenant in context")
+	ErrUnknownTenant  = errors.New("tenant not found")
+)
+
+func Valid(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, ch := range id {
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	if !ok || id == "" {
+		return "", ErrNoTenant
+	}
+	return id, nil
+}
+
+func Resolve(r *http.Request, header, defaultTenant string) (string, error) {
+	requested := r.Header.Get(header)
+	if requested != "" && !Valid(requested) {
+		return "", ErrInvalidTenant
+	}
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok || principal == nil:
+		if requested != "" {
+			return requested, nil
+		}
+		return defaultTenant, nil
+	case principal.TenantID != "":
+		if requested != "" && requested != principal.TenantID {
+			return "", ErrTenantMismatch
+		}
+		return principal.TenantID, nil
+	case requested == "" || requested == defaultTenant:
+		return defaultTenant, nil
+	case slices.Contains(principal.EffectiveRoles(), auth.RoleAdmin):
+		return requested, nil
+	}
+	return "", ErrTenantMismatch
+}
+
+func Middleware(header, defaultTenant string, known func(id string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := Resolve(r, header, defaultTenant)
+			if err == nil && known != nil && !known(id) {
+				err = ErrUnknownTenant
+			}
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrTenantMismatch):
+					http.Error(w, err.Error(), http.StatusForbidden)
+				case errors.Is(err, ErrUnknownTenant):
+					http.Error(w, err.Error(), http.StatusNotFound)
+				default:
+					http.Error(w, err.Error(), http.StatusBadRequest)
+				}
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), id)))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webServerEx/internal/auth"
+)
+
+func TestResolve(t *testing.T) {
+	tableTests := []struct {
+		name      string
+		header    string
+		principal *auth.Principal
+		tenant    string
+		err       error
+	}{
+		{name: "default tenant", tenant: "default"},
+		{name: "header tenant", header: "team-a", tenant: "team-a"},
+		{name: "invalid header", header: "Team A", err: ErrInvalidTenant},
+		{name: "principal tenant", principal: &auth.Principal{TenantID: "team-b"}, tenant: "team-b"},
+		{name: "matching header", header: "team-b", principal: &auth.Principal{TenantID: "team-b"}, tenant: "team-b"},
+		{name: "other tenant header", header: "team-a", principal: &auth.Principal{TenantID: "team-b"}, err: ErrTenantMismatch},
+		{name: "tenant-less jwt other header", header: "other", principal: &auth.Principal{ID: "alice", Kind: auth.KindJWT}, err: ErrTenantMismatch},
+		{name: "tenant-less jwt default", principal: &auth.Principal{ID: "alice", Kind: auth.KindJWT}, tenant: "default"},
+		{name: "tenant-less key default header", header: "default", principal: &auth.Principal{Kind: auth.KindAPIKey, Scopes: []string{auth.ScopeTasksWrite}}, tenant: "default"},
+		{name: "admin scope picks tenant", header: "team-a", principal: &auth.Principal{Kind: auth.KindAPIKey, Scopes: []string{auth.ScopeTasksAdmin}}, tenant: "team-a"},
+		{name: "admin role picks tenant", header: "team-a", principal: &auth.Principal{Kind: auth.KindJWT, Roles: []string{auth.RoleAdmin}}, tenant: "team-a"},
+	}
+	for _, tt := range tableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if tt.header != "" {
+				req.Header.Set(DefaultHeader, tt.header)
+			}
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+
+			tenant, err := Resolve(req, DefaultHeader, "default")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if tenant != tt.tenant {
+				t.Errorf("expected tenant %q, got %q", tt.tenant, tenant)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var resolved string
+	known := func(id string) bool { return id == "default" || id == "team-a" }
+	handler := Middleware(DefaultHeader, "default", known)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved, _ = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set(DefaultHeader, "team-a")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if resolved != "team-a" {
+		t.Errorf("uncorrect tenant: %s", resolved)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set(DefaultHeader, "team-a")
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{TenantID: "team-b"}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status http.StatusForbidden, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set(DefaultHeader, "unknown")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status http.StatusNotFound, got %d", rec.Code)
+	}
+}